@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"strconv"
 )
@@ -15,6 +14,8 @@ type ArchiveService struct {
 
 // DownloadArchive télécharge l'archive d'un cours
 func (s *ArchiveService) DownloadArchive(ctx context.Context, courseID string, opts *DownloadArchiveOptions) (io.ReadCloser, error) {
+	ctx = withRequiredFeature(ctx, FeatureArchive)
+
 	params := url.Values{}
 
 	if opts != nil {
@@ -26,21 +27,14 @@ func (s *ArchiveService) DownloadArchive(ctx context.Context, courseID string, o
 		}
 	}
 
-	path := fmt.Sprintf("/storage/courses/%s/archive", courseID)
-	if len(params) > 0 {
-		path += "?" + params.Encode()
-	}
-
-	resp, err := s.client.get(ctx, path)
+	resp, err := s.client.doChecked(ctx, &Request{
+		Path:  fmt.Sprintf("/storage/courses/%s/archive", courseID),
+		Query: params,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, parseAPIError(resp)
-	}
-
 	return resp.Body, nil
 }
 
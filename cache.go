@@ -0,0 +1,312 @@
+package ocfworker
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheEntries is the default number of responses kept for revalidation.
+	defaultCacheEntries = 256
+	// maxCachedBodySize is the largest response body kept in the cache.
+	maxCachedBodySize = 1 << 20
+)
+
+// WithResponseCache enables the in-memory cache of read-only responses
+// (Jobs.Get, Jobs.List, ListSources, ListResults, GetLogs, GetStorageInfo,
+// Worker.Stats and workspace queries). The cache is disabled by default.
+//
+// Once enabled, the client remembers the ETag and Last-Modified validators of these
+// responses and revalidates them with If-None-Match and If-Modified-Since: a
+// 304 Not Modified answer is served from memory instead of downloading the
+// body again. With a positive ttl, a cached response younger than ttl is
+// returned without contacting the worker at all.
+//
+// maxEntries bounds the number of cached responses (default 256); the least
+// recently used ones are evicted first. Any successful write (job creation,
+// upload, cleanup...) clears the cache. On a multi-endpoint client, responses
+// are cached per endpoint: validators of one worker are never sent to another.
+//
+// Example:
+//
+//	// Revalidate responses with ETag/Last-Modified only
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithResponseCache(0, 0))
+//
+//	// Serve job status polls from memory for up to 2 seconds
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithResponseCache(2*time.Second, 1000))
+func WithResponseCache(ttl time.Duration, maxEntries int) Option {
+	return func(c *Client) {
+		if maxEntries <= 0 {
+			maxEntries = defaultCacheEntries
+		}
+		c.cacheTTL = ttl
+		c.cacheEntries = maxEntries
+	}
+}
+
+// WithoutResponseCache disables conditional requests and response caching,
+// undoing an earlier WithResponseCache. The cache is disabled by default.
+func WithoutResponseCache() Option {
+	return func(c *Client) {
+		c.cacheEntries = 0
+	}
+}
+
+// isCacheable tells whether responses of an endpoint may be cached.
+// Downloads are streamed and never cached; health checks must reach the worker.
+func isCacheable(endpoint string) bool {
+	switch endpoint {
+	case "/jobs", "/jobs/:id",
+		"/storage/jobs/:id/sources", "/storage/jobs/:id/logs",
+		"/storage/courses/:id/results", "/storage/info",
+		"/worker/stats", "/worker/workspaces", "/worker/workspaces/:id":
+		return true
+	default:
+		return false
+	}
+}
+
+// sendCached executes a request through the response cache. It is called
+// once the endpoint of the request is resolved, so that responses are keyed
+// by the endpoint that served them.
+func (c *Client) sendCached(req *http.Request, requestID string) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		resp, err := c.sendWithRetries(req, requestID)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			c.cache.clear()
+		}
+		return resp, err
+	}
+
+	if !isCacheable(endpointLabel(req.URL.Path)) {
+		return c.sendWithRetries(req, requestID)
+	}
+
+	key := req.URL.String()
+	entry, cached := c.cache.get(key)
+	if cached {
+		if c.cache.fresh(entry) {
+			c.logger.Debug("Response served from cache", LogKeyPath, req.URL.Path, LogKeyRequestID, requestID)
+			return entry.response(req, requestID), nil
+		}
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := c.sendWithRetries(req, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		c.cache.touch(entry)
+		c.logger.Debug("Response not modified", LogKeyPath, req.URL.Path, LogKeyRequestID, requestID)
+		return entry.response(req, responseRequestID(resp)), nil
+	case resp.StatusCode == http.StatusOK:
+		return c.cache.store(key, resp), nil
+	default:
+		return resp, nil
+	}
+}
+
+// dispatch sends a request to its endpoint, through the endpoint pool of
+// multi-endpoint clients.
+func (c *Client) dispatch(req *http.Request, requestID string) (*http.Response, error) {
+	if c.pool != nil {
+		return c.pool.send(req, requestID)
+	}
+	if err := c.checkFeature(req, c.baseURL); err != nil {
+		return nil, err
+	}
+	return c.sendResolved(req, requestID)
+}
+
+// sendResolved sends a request whose URL points to its final endpoint,
+// through the response cache when it is enabled.
+func (c *Client) sendResolved(req *http.Request, requestID string) (*http.Response, error) {
+	if c.cache != nil {
+		return c.sendCached(req, requestID)
+	}
+	return c.sendWithRetries(req, requestID)
+}
+
+// responseCache keeps read-only responses with their validators.
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries *lruCache[*cachedResponse]
+}
+
+// cachedResponse is a stored response. Its body and header are never modified.
+type cachedResponse struct {
+	header       http.Header
+	body         []byte
+	etag         string
+	lastModified string
+
+	storedAt time.Time // guarded by responseCache.mu
+}
+
+func newResponseCache(ttl time.Duration, maxEntries int) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		entries: newLRUCache[*cachedResponse](maxEntries),
+	}
+}
+
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.get(key)
+}
+
+func (c *responseCache) fresh(entry *cachedResponse) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttl > 0 && time.Since(entry.storedAt) < c.ttl
+}
+
+// touch marks an entry as revalidated.
+func (c *responseCache) touch(entry *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.storedAt = time.Now()
+}
+
+func (c *responseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.clear()
+}
+
+// store caches a 200 response when it carries validators or a TTL applies,
+// and returns a response whose body can still be read by the caller.
+func (c *responseCache) store(key string, resp *http.Response) *http.Response {
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" && c.ttl <= 0 {
+		return resp
+	}
+	if resp.ContentLength > maxCachedBodySize {
+		return resp
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBodySize+1))
+	if err != nil || len(body) > maxCachedBodySize {
+		// Hand the caller what was read followed by the rest of the body
+		resp.Body = &struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.set(key, &cachedResponse{
+		header:       resp.Header.Clone(),
+		body:         body,
+		etag:         etag,
+		lastModified: lastModified,
+		storedAt:     time.Now(),
+	})
+
+	return resp
+}
+
+// response builds a 200 response from the cached entry.
+func (e *cachedResponse) response(req *http.Request, requestID string) *http.Response {
+	header := e.header.Clone()
+	if requestID != "" {
+		header.Set(RequestIDHeader, requestID)
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// lruCache is a bounded map evicting the least recently used entries.
+// It is not safe for concurrent use.
+type lruCache[V any] struct {
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+func newLRUCache[V any](max int) *lruCache[V] {
+	return &lruCache[V]{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache[V]) get(key string) (V, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry[V]).value, true
+}
+
+func (c *lruCache[V]) set(key string, value V) {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruEntry[V]).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value})
+
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+func (c *lruCache[V]) remove(key string) {
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+func (c *lruCache[V]) clear() {
+	c.order.Init()
+	clear(c.entries)
+}
+
+func (c *lruCache[V]) len() int {
+	return c.order.Len()
+}
@@ -0,0 +1,193 @@
+package ocfworker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	var calls, notModified atomic.Int32
+
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		RespondJSON(w, http.StatusOK, NewJobResponse().WithID(jobID).WithStatus(models.StatusProcessing).Build())
+	})
+
+	client := server.TestClient(WithResponseCache(0, 0))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		job, err := client.Jobs.Get(ctx, jobID.String())
+		require.NoError(t, err)
+		assert.Equal(t, jobID, job.ID)
+		assert.Equal(t, models.StatusProcessing, job.Status)
+	}
+
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, int32(2), notModified.Load())
+}
+
+func TestConditionalRequests_LastModified(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	courseID := uuid.New().String()
+	lastModified := time.Now().UTC().Format(http.TimeFormat)
+	var notModified atomic.Int32
+
+	server.On("GET", "/api/v1/storage/courses/"+courseID+"/results", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		RespondJSON(w, http.StatusOK, MockFileList("index.html"))
+	})
+
+	client := server.TestClient(WithResponseCache(0, 0))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		results, err := client.Storage.ListResults(ctx, courseID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, results.Count)
+	}
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestResponseCache_TTL(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var statsCalls atomic.Int32
+	server.On("GET", "/api/v1/worker/stats", func(w http.ResponseWriter, r *http.Request) {
+		statsCalls.Add(1)
+		RespondJSON(w, http.StatusOK, &models.WorkerStatsResponse{})
+	})
+	server.On("POST", "/api/v1/generate", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusCreated, NewJobResponse().Build())
+	})
+
+	client := server.TestClient(WithResponseCache(time.Minute, 10))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Worker.Stats(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), statsCalls.Load())
+
+	// A write clears the cache
+	_, err := client.Jobs.Create(ctx, MockGenerationRequest())
+	require.NoError(t, err)
+
+	_, err = client.Worker.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), statsCalls.Load())
+}
+
+func TestResponseCache_Disabled(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		RespondJSON(w, http.StatusOK, NewJobResponse().WithID(jobID).Build())
+	})
+
+	// Disabled by default, and by WithoutResponseCache
+	for _, client := range []*Client{
+		server.TestClient(),
+		server.TestClient(WithResponseCache(0, 0), WithoutResponseCache()),
+	} {
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		for i := 0; i < 2; i++ {
+			_, err := client.Jobs.Get(ctx, jobID.String())
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestResponseCache_PerEndpoint(t *testing.T) {
+	// Two workers answering the same route with their own ETag
+	newWorker := func(etag string, notModified *atomic.Int32) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v1/jobs" {
+				RespondError(w, http.StatusNotFound, "route not found")
+				return
+			}
+			if inm := r.Header.Get("If-None-Match"); inm != "" {
+				assert.Equal(t, etag, inm, "validator of another endpoint")
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			RespondJSON(w, http.StatusOK, &models.JobListResponse{})
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	var firstNotModified, secondNotModified atomic.Int32
+	first := newWorker(`"first"`, &firstNotModified)
+	second := newWorker(`"second"`, &secondNotModified)
+
+	client := NewMultiClient([]Endpoint{{URL: first.URL}, {URL: second.URL}}, WithResponseCache(0, 0))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 4; i++ {
+		_, err := client.Jobs.List(ctx, nil)
+		require.NoError(t, err)
+	}
+	// Requests alternate between the workers, each revalidating its own entry
+	assert.Equal(t, int32(1), firstNotModified.Load())
+	assert.Equal(t, int32(1), secondNotModified.Load())
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := newLRUCache[int](2)
+
+	cache.set("a", 1)
+	cache.set("b", 2)
+	_, _ = cache.get("a")
+	cache.set("c", 3)
+
+	_, ok := cache.get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+
+	value, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, cache.len())
+
+	cache.clear()
+	assert.Equal(t, 0, cache.len())
+}
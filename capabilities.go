@@ -0,0 +1,364 @@
+package ocfworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// APIVersionV1 is the original worker API, served under /api/v1.
+	APIVersionV1 = "v1"
+	// APIVersionV2 is the worker API served under /api/v2.
+	APIVersionV2 = "v2"
+	// APIVersionAuto selects the most recent API version supported by both
+	// the SDK and the worker, as reported by Capabilities.
+	APIVersionAuto = "auto"
+)
+
+// capabilitiesRetryInterval is how long a failed discovery is remembered
+// before the worker is probed again. Meanwhile APIVersionAuto uses v1.
+const capabilitiesRetryInterval = 30 * time.Second
+
+// supportedAPIVersions lists the API versions known to the SDK, most recent first.
+var supportedAPIVersions = []string{APIVersionV2, APIVersionV1}
+
+// Feature names an optional capability of a worker.
+type Feature string
+
+const (
+	// FeatureLogs covers job log retrieval (Storage.GetLogs).
+	FeatureLogs Feature = "logs"
+	// FeatureWorkspaces covers workspace inspection and cleanup.
+	FeatureWorkspaces Feature = "workspaces"
+	// FeatureArchive covers course archive downloads.
+	FeatureArchive Feature = "archive"
+	// FeatureWorkerStats covers worker pool statistics.
+	FeatureWorkerStats Feature = "worker_stats"
+)
+
+// v1Features are the features every v1 worker provides. They are assumed
+// when the worker has no discovery endpoint.
+var v1Features = []Feature{FeatureLogs, FeatureWorkspaces, FeatureArchive, FeatureWorkerStats}
+
+// Capabilities describes what a worker supports.
+type Capabilities struct {
+	// Version is the worker version (e.g. "2.0.0")
+	Version string `json:"version"`
+
+	// APIVersions lists the API versions served by the worker (e.g. ["v1", "v2"])
+	APIVersions []string `json:"api_versions"`
+
+	// Features lists the optional features enabled on the worker
+	Features []Feature `json:"features"`
+}
+
+// Supports tells whether the worker provides a feature.
+func (c *Capabilities) Supports(feature Feature) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// SupportsAPIVersion tells whether the worker serves an API version.
+func (c *Capabilities) SupportsAPIVersion(version string) bool {
+	return slices.Contains(c.APIVersions, version)
+}
+
+// preferredAPIVersion returns the most recent API version known to both sides.
+func (c *Capabilities) preferredAPIVersion() string {
+	for _, version := range supportedAPIVersions {
+		if c.SupportsAPIVersion(version) {
+			return version
+		}
+	}
+	return APIVersionV1
+}
+
+// UnsupportedFeatureError is returned without contacting the worker when a
+// call needs a feature that the worker's capabilities don't list.
+//
+// It is only returned once the capabilities of the endpoint are known, i.e.
+// after Capabilities has been called (explicitly or through
+// WithAPIVersion(APIVersionAuto)).
+//
+// Example usage:
+//
+//	_, err := client.Archive.DownloadArchive(ctx, courseID, nil)
+//	var unsupported *ocfworker.UnsupportedFeatureError
+//	if errors.As(err, &unsupported) {
+//		log.Printf("worker %s has no %s support", unsupported.ServerVersion, unsupported.Feature)
+//	}
+type UnsupportedFeatureError struct {
+	// Feature is the missing feature
+	Feature Feature
+
+	// ServerVersion is the version reported by the worker
+	ServerVersion string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("feature %q is not supported by worker version %s", e.Feature, e.ServerVersion)
+}
+
+// WithAPIVersion selects the API version used for all routes: APIVersionV1
+// (default), APIVersionV2, or APIVersionAuto to negotiate it with the worker
+// through Capabilities on the first call.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithAPIVersion(ocfworker.APIVersionAuto))
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// Capabilities returns the version, API versions and features of the worker.
+//
+// It queries the /api/version discovery endpoint and, on workers that don't
+// provide it, falls back to the version reported by Health.Check with the v1
+// feature set. The result is cached for the lifetime of the client. A failure
+// is returned again, without contacting the worker, for 30 seconds; failures
+// due to a canceled or expired context are not remembered.
+//
+// Concurrent calls share a single discovery request.
+//
+// On a multi-endpoint client, Capabilities describes the first endpoint.
+// Version negotiation and feature checks use the capabilities of the
+// endpoint each request is routed to, discovered and cached separately.
+//
+// Example:
+//
+//	caps, err := client.Capabilities(ctx)
+//	if err == nil && caps.Supports(ocfworker.FeatureArchive) {
+//		// ...
+//	}
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	return c.endpointCapabilities(ctx, c.baseURL)
+}
+
+// capabilitiesState is what is known of the capabilities of one endpoint.
+type capabilitiesState struct {
+	caps  *Capabilities
+	call  *capabilitiesCall
+	err   error
+	errAt time.Time
+}
+
+// capabilitiesCall is a discovery in progress, shared by concurrent callers.
+type capabilitiesCall struct {
+	done chan struct{}
+	caps *Capabilities
+	err  error
+	// canceled tells that the discovery was interrupted by the context of
+	// the caller that performed it
+	canceled bool
+}
+
+// capabilitiesState returns the state of an endpoint. c.capsMu must be held.
+func (c *Client) capabilitiesState(endpoint string) *capabilitiesState {
+	if c.caps == nil {
+		c.caps = make(map[string]*capabilitiesState)
+	}
+	state, ok := c.caps[endpoint]
+	if !ok {
+		state = &capabilitiesState{}
+		c.caps[endpoint] = state
+	}
+	return state
+}
+
+// endpointCapabilities returns the capabilities of the endpoint with the
+// given base URL, discovering them on first use.
+func (c *Client) endpointCapabilities(ctx context.Context, endpoint string) (*Capabilities, error) {
+	for {
+		c.capsMu.Lock()
+		state := c.capabilitiesState(endpoint)
+		if state.caps != nil {
+			defer c.capsMu.Unlock()
+			return state.caps, nil
+		}
+		if state.err != nil && time.Since(state.errAt) < capabilitiesRetryInterval {
+			defer c.capsMu.Unlock()
+			return nil, state.err
+		}
+		call := state.call
+		if call == nil {
+			break
+		}
+		c.capsMu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// The caller that performed the discovery gave up: try again with
+		// this caller's context rather than returning its cancellation
+		if !call.canceled {
+			return call.caps, call.err
+		}
+	}
+
+	// c.capsMu is held
+	state := c.caps[endpoint]
+	call := &capabilitiesCall{done: make(chan struct{})}
+	state.call = call
+	c.capsMu.Unlock()
+
+	call.caps, call.err = c.discoverCapabilities(ctx, endpoint)
+	// Failures due to the caller's context say nothing about the worker
+	call.canceled = call.err != nil && (ctx.Err() != nil ||
+		errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded))
+
+	c.capsMu.Lock()
+	state.call = nil
+	switch {
+	case call.err == nil:
+		state.caps = call.caps
+	case !call.canceled:
+		state.err = call.err
+		state.errAt = time.Now()
+	}
+	c.capsMu.Unlock()
+	close(call.done)
+
+	return call.caps, call.err
+}
+
+// discoverCapabilities queries an endpoint for its capabilities.
+func (c *Client) discoverCapabilities(ctx context.Context, endpoint string) (*Capabilities, error) {
+	// Discovery requests must not wait for the negotiation they perform, and
+	// must reach the endpoint they describe
+	ctx = withoutNegotiation(ctx)
+	if c.pool != nil {
+		ctx = withEndpoint(ctx, endpoint)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/version", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var caps Capabilities
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := c.decodeResponse(resp, &caps); err != nil {
+			return nil, err
+		}
+		if len(caps.APIVersions) == 0 {
+			caps.APIVersions = []string{APIVersionV1}
+		}
+	case http.StatusNotFound:
+		health, err := c.Health.Check(ctx)
+		if err != nil {
+			return nil, err
+		}
+		caps = Capabilities{
+			Version:     health.Version,
+			APIVersions: []string{APIVersionV1},
+			Features:    v1Features,
+		}
+	default:
+		return nil, parseAPIError(resp)
+	}
+
+	c.logger.Debug("Worker capabilities discovered", "endpoint", endpoint, "version", caps.Version,
+		"api_versions", strings.Join(caps.APIVersions, ","))
+
+	return &caps, nil
+}
+
+// cachedCapabilities returns the capabilities of an endpoint if they were
+// already discovered.
+func (c *Client) cachedCapabilities(endpoint string) *Capabilities {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if state, ok := c.caps[endpoint]; ok {
+		return state.caps
+	}
+	return nil
+}
+
+// requireFeature returns an UnsupportedFeatureError when the cached
+// capabilities of an endpoint are known and lack feature. It never contacts
+// the worker.
+func (c *Client) requireFeature(endpoint string, feature Feature) error {
+	caps := c.cachedCapabilities(endpoint)
+	if caps == nil || caps.Supports(feature) {
+		return nil
+	}
+	return &UnsupportedFeatureError{Feature: feature, ServerVersion: caps.Version}
+}
+
+type requiredFeatureKey struct{}
+
+// withRequiredFeature marks the requests made with ctx as needing feature,
+// checked once the endpoint of each request is known.
+func withRequiredFeature(ctx context.Context, feature Feature) context.Context {
+	return context.WithValue(ctx, requiredFeatureKey{}, feature)
+}
+
+// checkFeature checks the feature required by a request, if any, against
+// the endpoint it is sent to.
+func (c *Client) checkFeature(req *http.Request, endpoint string) error {
+	feature, ok := req.Context().Value(requiredFeatureKey{}).(Feature)
+	if !ok {
+		return nil
+	}
+	return c.requireFeature(endpoint, feature)
+}
+
+type negotiationBypassKey struct{}
+
+func withoutNegotiation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, negotiationBypassKey{}, true)
+}
+
+// apiPath prefixes path with the API version in use.
+//
+// Multi-endpoint clients negotiating the version use v1 here: the prefix is
+// switched to the version of the endpoint once the request is routed.
+func (c *Client) apiPath(ctx context.Context, path string) string {
+	version := c.apiVersion
+	if version == APIVersionAuto {
+		version = APIVersionV1
+		if bypass, _ := ctx.Value(negotiationBypassKey{}).(bool); !bypass && c.pool == nil {
+			if caps, err := c.Capabilities(ctx); err == nil {
+				version = caps.preferredAPIVersion()
+			}
+		}
+	}
+	return "/api/" + version + path
+}
+
+// endpointAPIPath switches the API version prefix of a routed request path
+// (with its query string) to the version negotiated with its endpoint.
+func (c *Client) endpointAPIPath(ctx context.Context, endpoint, path string) string {
+	if c.apiVersion != APIVersionAuto {
+		return path
+	}
+	if bypass, _ := ctx.Value(negotiationBypassKey{}).(bool); bypass {
+		return path
+	}
+	rest := trimAPIPrefix(path)
+	if rest == path {
+		return path
+	}
+
+	version := APIVersionV1
+	if caps, err := c.endpointCapabilities(ctx, endpoint); err == nil {
+		version = caps.preferredAPIVersion()
+	}
+	return "/api/" + version + rest
+}
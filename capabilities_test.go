@@ -0,0 +1,305 @@
+package ocfworker
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities_Discovery(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var discoveries, archiveCalls atomic.Int32
+	server.On("GET", "/api/version", func(w http.ResponseWriter, r *http.Request) {
+		discoveries.Add(1)
+		RespondJSON(w, http.StatusOK, &Capabilities{
+			Version:     "3.1.0",
+			APIVersions: []string{"v1", "v2"},
+			Features:    []Feature{FeatureLogs, FeatureWorkspaces},
+		})
+	})
+	courseID := uuid.New().String()
+	server.On("GET", "/api/v1/storage/courses/"+courseID+"/archive", func(w http.ResponseWriter, r *http.Request) {
+		archiveCalls.Add(1)
+		w.Write([]byte("zip"))
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	// Unknown capabilities never block a call
+	body, err := client.Archive.DownloadArchive(ctx, courseID, nil)
+	require.NoError(t, err)
+	body.Close()
+
+	for i := 0; i < 2; i++ {
+		caps, err := client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "3.1.0", caps.Version)
+		assert.True(t, caps.SupportsAPIVersion(APIVersionV2))
+		assert.True(t, caps.Supports(FeatureLogs))
+		assert.False(t, caps.Supports(FeatureArchive))
+	}
+	assert.Equal(t, int32(1), discoveries.Load())
+
+	_, err = client.Archive.DownloadArchive(ctx, courseID, nil)
+	var unsupported *UnsupportedFeatureError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, FeatureArchive, unsupported.Feature)
+	assert.Equal(t, "3.1.0", unsupported.ServerVersion)
+	assert.Equal(t, int32(1), archiveCalls.Load())
+}
+
+func TestCapabilities_HealthFallback(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, &models.HealthResponse{Status: "healthy", Version: "2.0.0"})
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	caps, err := client.Capabilities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", caps.Version)
+	assert.Equal(t, []string{APIVersionV1}, caps.APIVersions)
+	assert.True(t, caps.Supports(FeatureArchive))
+	assert.True(t, caps.Supports(FeatureWorkspaces))
+}
+
+func TestCapabilities_Unreachable(t *testing.T) {
+	server := NewTestServer()
+	server.Close()
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	_, err := client.Capabilities(ctx)
+	require.Error(t, err)
+	assert.Nil(t, client.cachedCapabilities(client.baseURL))
+}
+
+func TestCapabilities_FailureCachedBriefly(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var discoveries atomic.Int32
+	server.On("GET", "/api/version", func(w http.ResponseWriter, r *http.Request) {
+		discoveries.Add(1)
+		RespondError(w, http.StatusInternalServerError, "boom")
+	})
+	jobID := uuid.New()
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, NewJobResponse().WithID(jobID).Build())
+	})
+
+	client := server.TestClient(WithAPIVersion(APIVersionAuto))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	// Every call falls back to v1, probing the worker only once
+	for i := 0; i < 3; i++ {
+		_, err := client.Jobs.Get(ctx, jobID.String())
+		require.NoError(t, err)
+	}
+	_, err := client.Capabilities(ctx)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), discoveries.Load())
+
+	// The failure expires
+	client.capsMu.Lock()
+	client.caps[client.baseURL].errAt = time.Now().Add(-capabilitiesRetryInterval)
+	client.capsMu.Unlock()
+	_, err = client.Capabilities(ctx)
+	require.Error(t, err)
+	assert.Equal(t, int32(2), discoveries.Load())
+}
+
+func TestCapabilities_ConcurrentCallsShareDiscovery(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var discoveries atomic.Int32
+	release := make(chan struct{})
+	server.On("GET", "/api/version", func(w http.ResponseWriter, r *http.Request) {
+		discoveries.Add(1)
+		<-release
+		RespondJSON(w, http.StatusOK, &Capabilities{Version: "3.0.0", APIVersions: []string{"v1"}})
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			caps, err := client.Capabilities(ctx)
+			assert.NoError(t, err)
+			if caps != nil {
+				assert.Equal(t, "3.0.0", caps.Version)
+			}
+		}()
+	}
+
+	// Waiting callers don't hold the lock: cached state stays readable
+	require.Eventually(t, func() bool { return discoveries.Load() == 1 }, time.Second, time.Millisecond)
+	assert.Nil(t, client.cachedCapabilities(client.baseURL))
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), discoveries.Load())
+}
+
+func TestCapabilities_CanceledDiscoveryNotShared(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var discoveries atomic.Int32
+	server.On("GET", "/api/version", func(w http.ResponseWriter, r *http.Request) {
+		if discoveries.Add(1) == 1 {
+			// The first discovery hangs until its caller gives up
+			<-r.Context().Done()
+			return
+		}
+		RespondJSON(w, http.StatusOK, &Capabilities{Version: "3.0.0", APIVersions: []string{"v1"}})
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	leaderCtx, cancelLeader := context.WithCancel(ctx)
+	leaderDone := make(chan error)
+	go func() {
+		_, err := client.Capabilities(leaderCtx)
+		leaderDone <- err
+	}()
+	require.Eventually(t, func() bool { return discoveries.Load() == 1 }, time.Second, time.Millisecond)
+
+	followerDone := make(chan *Capabilities)
+	go func() {
+		caps, err := client.Capabilities(ctx)
+		assert.NoError(t, err)
+		followerDone <- caps
+	}()
+
+	cancelLeader()
+	assert.ErrorIs(t, <-leaderDone, context.Canceled)
+
+	// The follower performs its own discovery instead of failing with the
+	// leader's cancellation, which is not remembered
+	caps := <-followerDone
+	require.NotNil(t, caps)
+	assert.Equal(t, "3.0.0", caps.Version)
+	assert.Equal(t, int32(2), discoveries.Load())
+}
+
+func TestCapabilities_PerEndpoint(t *testing.T) {
+	// old is a v1 worker without stats, current serves v2 with stats
+	old := NewTestServer()
+	defer old.Close()
+	current := NewTestServer()
+	defer current.Close()
+
+	jobID := uuid.New()
+	for server, version := range map[*TestServer]string{old: "v1", current: "v2"} {
+		caps := &Capabilities{Version: "2.0.0", APIVersions: []string{"v1"}}
+		if version == "v2" {
+			caps = &Capabilities{Version: "3.0.0", APIVersions: []string{"v1", "v2"}, Features: []Feature{FeatureWorkerStats}}
+		}
+		server.On("GET", "/api/version", func(w http.ResponseWriter, r *http.Request) {
+			RespondJSON(w, http.StatusOK, caps)
+		})
+		server.On("GET", "/api/"+version+"/worker/health", func(w http.ResponseWriter, r *http.Request) {
+			RespondJSON(w, http.StatusOK, &models.WorkerHealthResponse{Status: "healthy"})
+		})
+		server.On("GET", "/api/"+version+"/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+			RespondJSON(w, http.StatusOK, NewJobResponse().WithID(jobID).Build())
+		})
+	}
+	var stats atomic.Int32
+	current.On("GET", "/api/v2/worker/stats", func(w http.ResponseWriter, r *http.Request) {
+		stats.Add(1)
+		RespondJSON(w, http.StatusOK, &models.WorkerStatsResponse{})
+	})
+
+	client := NewMultiClient([]Endpoint{{URL: old.URL}, {URL: current.URL}}, WithAPIVersion(APIVersionAuto))
+	t.Cleanup(func() { client.Close(context.Background()) })
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for _, server := range []*TestServer{old, current} {
+		_, err := client.endpointCapabilities(ctx, server.URL)
+		require.NoError(t, err)
+	}
+
+	// Each endpoint is called with its own API version
+	job, err := client.Jobs.Get(withEndpoint(ctx, old.URL), jobID.String())
+	require.NoError(t, err)
+	assert.Equal(t, jobID, job.ID)
+	job, err = client.Jobs.Get(withEndpoint(ctx, current.URL), jobID.String())
+	require.NoError(t, err)
+	assert.Equal(t, jobID, job.ID)
+
+	// Features are checked against the endpoint of the call
+	_, err = client.Worker.Stats(withEndpoint(ctx, old.URL))
+	var unsupported *UnsupportedFeatureError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, "2.0.0", unsupported.ServerVersion)
+
+	// Calls not bound to an endpoint go to one providing the feature
+	for i := 0; i < 3; i++ {
+		_, err = client.Worker.Stats(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), stats.Load())
+}
+
+func TestAPIVersionNegotiation(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	server.On("GET", "/api/version", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, &Capabilities{Version: "3.0.0", APIVersions: []string{"v1", "v2"}})
+	})
+	server.On("GET", "/api/v2/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, NewJobResponse().WithID(jobID).Build())
+	})
+
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	job, err := server.TestClient(WithAPIVersion(APIVersionAuto)).Jobs.Get(ctx, jobID.String())
+	require.NoError(t, err)
+	assert.Equal(t, jobID, job.ID)
+
+	job, err = server.TestClient(WithAPIVersion(APIVersionV2)).Jobs.Get(ctx, jobID.String())
+	require.NoError(t, err)
+	assert.Equal(t, jobID, job.ID)
+}
+
+func TestTrimAPIPrefix(t *testing.T) {
+	assert.Equal(t, "/jobs/1", trimAPIPrefix("/api/v1/jobs/1"))
+	assert.Equal(t, "/health", trimAPIPrefix("/api/v2/health"))
+	assert.Equal(t, "/api/version", trimAPIPrefix("/api/version"))
+	assert.Equal(t, "/other", trimAPIPrefix("/other"))
+	assert.Equal(t, "/jobs/:id", endpointLabel("/api/v2/jobs/abc"))
+}
@@ -0,0 +1,293 @@
+package ocfworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through while failures are counted.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all requests with a CircuitOpenError.
+	CircuitOpen
+	// CircuitHalfOpen is the transient state where the endpoint is probed
+	// with a health check before traffic resumes.
+	CircuitHalfOpen
+)
+
+// String returns the lowercase name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerConfig configures the per-endpoint circuit breaker.
+// Zero values are replaced by the documented defaults.
+type CircuitBreakerConfig struct {
+	// FailureRate is the ratio of failed requests (0-1) within Window that
+	// opens the circuit. Default: 0.5.
+	FailureRate float64
+
+	// MinRequests is the minimum number of requests within Window before the
+	// failure rate is evaluated. Default: 10.
+	MinRequests int
+
+	// Window is the sliding time window over which outcomes are counted.
+	// Default: 30 seconds.
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before the endpoint is
+	// probed with Health.Check. Default: 30 seconds.
+	OpenTimeout time.Duration
+
+	// OnStateChange, if set, is called on every state transition.
+	// It is called synchronously and must not block.
+	OnStateChange func(endpoint string, from, to CircuitState)
+}
+
+// WithCircuitBreaker enables a circuit breaker per worker endpoint.
+//
+// Transport errors and 5xx responses count as failures. When the failure
+// rate exceeds the threshold, the circuit opens and every call fails fast
+// with a CircuitOpenError. After OpenTimeout, the next call probes the
+// endpoint with Health.Check: the circuit closes if the worker reports
+// itself as not unhealthy, and opens again otherwise.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL,
+//		ocfworker.WithCircuitBreaker(ocfworker.CircuitBreakerConfig{
+//			FailureRate: 0.5,
+//			OnStateChange: func(endpoint string, from, to ocfworker.CircuitState) {
+//				alerting.Notify("ocf worker %s circuit %s -> %s", endpoint, from, to)
+//			},
+//		}),
+//	)
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(c *Client) {
+		if config.FailureRate <= 0 || config.FailureRate > 1 {
+			config.FailureRate = 0.5
+		}
+		if config.MinRequests <= 0 {
+			config.MinRequests = 10
+		}
+		if config.Window <= 0 {
+			config.Window = 30 * time.Second
+		}
+		if config.OpenTimeout <= 0 {
+			config.OpenTimeout = 30 * time.Second
+		}
+		c.breakerConfig = &config
+	}
+}
+
+// CircuitOpenError is returned without contacting the worker when the
+// circuit breaker for its endpoint is open.
+//
+// Example usage:
+//
+//	_, err := client.Jobs.CreateAndWait(ctx, req, nil)
+//	var openErr *ocfworker.CircuitOpenError
+//	if errors.As(err, &openErr) {
+//		log.Printf("worker %s unavailable, retry after %s", openErr.Endpoint, openErr.RetryAfter)
+//	}
+type CircuitOpenError struct {
+	// Endpoint is the worker endpoint (scheme and host) whose circuit is open
+	Endpoint string
+
+	// RetryAfter is the time remaining before the endpoint is probed again
+	RetryAfter time.Duration
+
+	// RequestID is the ID of the rejected request (see RequestIDFromError)
+	RequestID string
+}
+
+// Error implements the error interface.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s (retry after %s)", e.Endpoint, e.RetryAfter.Round(time.Second))
+}
+
+// IsTemporary returns true: the circuit closes again once the endpoint recovers.
+func (e *CircuitOpenError) IsTemporary() bool {
+	return true
+}
+
+// circuitBreaker tracks request outcomes for one endpoint.
+type circuitBreaker struct {
+	endpoint string
+	config   *CircuitBreakerConfig
+	probe    func(ctx context.Context) error
+
+	mu        sync.Mutex
+	state     CircuitState
+	openUntil time.Time
+	outcomes  []circuitOutcome
+}
+
+type circuitOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// allow returns nil if a request may be sent to the endpoint.
+// When the open timeout has elapsed, the calling goroutine runs the probe.
+func (b *circuitBreaker) allow(ctx context.Context) error {
+	b.mu.Lock()
+	switch b.state {
+	case CircuitClosed:
+		b.mu.Unlock()
+		return nil
+	case CircuitHalfOpen:
+		b.mu.Unlock()
+		return &CircuitOpenError{Endpoint: b.endpoint}
+	}
+
+	if remaining := time.Until(b.openUntil); remaining > 0 {
+		b.mu.Unlock()
+		return &CircuitOpenError{Endpoint: b.endpoint, RetryAfter: remaining}
+	}
+
+	b.setState(CircuitHalfOpen)
+	b.mu.Unlock()
+
+	err := b.probe(withoutCircuitBreaker(ctx))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.openUntil = time.Now().Add(b.config.OpenTimeout)
+		b.setState(CircuitOpen)
+		return &CircuitOpenError{Endpoint: b.endpoint, RetryAfter: b.config.OpenTimeout}
+	}
+
+	b.outcomes = nil
+	b.setState(CircuitClosed)
+	return nil
+}
+
+// record registers the outcome of a request sent while the circuit was closed.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != CircuitClosed {
+		return
+	}
+
+	now := time.Now()
+	b.outcomes = append(b.outcomes, circuitOutcome{at: now, failed: failed})
+
+	// Drop outcomes that left the window
+	cutoff := now.Add(-b.config.Window)
+	first := 0
+	for first < len(b.outcomes) && b.outcomes[first].at.Before(cutoff) {
+		first++
+	}
+	b.outcomes = b.outcomes[first:]
+
+	if len(b.outcomes) < b.config.MinRequests {
+		return
+	}
+
+	failures := 0
+	for _, outcome := range b.outcomes {
+		if outcome.failed {
+			failures++
+		}
+	}
+
+	if float64(failures)/float64(len(b.outcomes)) >= b.config.FailureRate {
+		b.openUntil = now.Add(b.config.OpenTimeout)
+		b.outcomes = nil
+		b.setState(CircuitOpen)
+	}
+}
+
+// setState changes the state and notifies the callback. Must be called with mu held.
+func (b *circuitBreaker) setState(state CircuitState) {
+	from := b.state
+	b.state = state
+	if from != state && b.config.OnStateChange != nil {
+		b.config.OnStateChange(b.endpoint, from, state)
+	}
+}
+
+type circuitBypassKey struct{}
+
+// withoutCircuitBreaker marks a context so that its requests skip the breaker.
+// It is used by the half-open probe.
+func withoutCircuitBreaker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, circuitBypassKey{}, true)
+}
+
+// circuitBreakerFor returns the breaker guarding the endpoint of u,
+// or nil if circuit breaking is disabled or bypassed for this request.
+func (c *Client) circuitBreakerFor(ctx context.Context, u *url.URL) *circuitBreaker {
+	if c.breakerConfig == nil {
+		return nil
+	}
+	if bypass, _ := ctx.Value(circuitBypassKey{}).(bool); bypass {
+		return nil
+	}
+
+	endpoint := u.Scheme + "://" + u.Host
+
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+
+	if c.breakers == nil {
+		c.breakers = make(map[string]*circuitBreaker)
+	}
+
+	breaker, ok := c.breakers[endpoint]
+	if !ok {
+		breaker = &circuitBreaker{
+			endpoint: endpoint,
+			config:   c.breakerConfig,
+			probe: func(ctx context.Context) error {
+				return c.probeHealth(withEndpoint(ctx, endpoint))
+			},
+		}
+		c.breakers[endpoint] = breaker
+	}
+
+	return breaker
+}
+
+// probeHealth checks that the worker answers its health check and is not unhealthy.
+func (c *Client) probeHealth(ctx context.Context) error {
+	health, err := c.Health.Check(ctx)
+	if err != nil {
+		return err
+	}
+	if health.Status == "unhealthy" {
+		return fmt.Errorf("worker reported status %s", health.Status)
+	}
+	return nil
+}
+
+// isCircuitFailure tells whether a request outcome counts as a failure for the breaker.
+// Cancellations initiated by the caller are not the endpoint's fault.
+func isCircuitFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
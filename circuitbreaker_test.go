@@ -0,0 +1,119 @@
+package ocfworker
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var (
+		healthy   atomic.Bool
+		jobCalls  atomic.Int32
+		mu        sync.Mutex
+		stateLogs []string
+	)
+
+	server.On("GET", "/api/v1/jobs/flaky", func(w http.ResponseWriter, r *http.Request) {
+		jobCalls.Add(1)
+		if healthy.Load() {
+			RespondJSON(w, http.StatusOK, NewJobResponse().Build())
+			return
+		}
+		RespondError(w, http.StatusBadGateway, "worker down")
+	})
+	server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		if healthy.Load() {
+			RespondJSON(w, http.StatusOK, MockHealthResponse("healthy"))
+			return
+		}
+		RespondJSON(w, http.StatusServiceUnavailable, MockHealthResponse("unhealthy"))
+	})
+
+	client := server.TestClient(WithCircuitBreaker(CircuitBreakerConfig{
+		FailureRate: 0.5,
+		MinRequests: 3,
+		Window:      time.Minute,
+		OpenTimeout: 50 * time.Millisecond,
+		OnStateChange: func(endpoint string, from, to CircuitState) {
+			mu.Lock()
+			stateLogs = append(stateLogs, from.String()+"->"+to.String())
+			mu.Unlock()
+		},
+	}))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	// Three server errors open the circuit
+	for i := 0; i < 3; i++ {
+		_, err := client.Jobs.Get(ctx, "flaky")
+		AssertAPIError(t, err, http.StatusBadGateway, "")
+	}
+
+	// Further calls fail fast without reaching the server
+	_, err := client.Jobs.Get(ctx, "flaky")
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, server.URL, openErr.Endpoint)
+	assert.True(t, openErr.IsTemporary())
+	assert.NotEmpty(t, RequestIDFromError(err))
+	assert.Equal(t, int32(3), jobCalls.Load())
+
+	// After the open timeout, the probe fails and the circuit opens again
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.Jobs.Get(ctx, "flaky")
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, int32(3), jobCalls.Load())
+
+	// Once the worker recovers, the probe closes the circuit
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.Jobs.Get(ctx, "flaky")
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), jobCalls.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, stateLogs)
+}
+
+func TestCircuitBreaker_ClientErrorsDoNotOpen(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/jobs/missing", func(w http.ResponseWriter, r *http.Request) {
+		RespondError(w, http.StatusNotFound, "not found")
+	})
+
+	client := server.TestClient(WithCircuitBreaker(CircuitBreakerConfig{MinRequests: 2}))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Jobs.Get(ctx, "missing")
+		require.Error(t, err)
+		assert.False(t, errors.As(err, new(*CircuitOpenError)))
+	}
+}
+
+func TestCircuitState_String(t *testing.T) {
+	assert.Equal(t, "closed", CircuitClosed.String())
+	assert.Equal(t, "open", CircuitOpen.String())
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+	assert.Equal(t, "CircuitState(42)", CircuitState(42).String())
+}
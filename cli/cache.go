@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"ocf-worker-sdk/pkg/generator"
+)
+
+// cacheCmd représente la commande cache
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Gestion du cache des sources",
+	Long: `Commandes pour gérer le cache des sources téléchargées par generate.
+
+Les sources sont conservées par fournisseur, dépôt et commit : générer à
+nouveau le même commit ne retélécharge rien. Au-delà de la taille maximale
+(cache-max-size, 1G par défaut), les entrées les moins récemment utilisées
+sont supprimées.
+
+Exemples:
+  ocf-worker-cli cache list
+  ocf-worker-cli cache prune --max-size 200M
+  ocf-worker-cli cache clear`,
+}
+
+// cacheListCmd liste les entrées du cache
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les sources en cache",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheList,
+}
+
+// cachePruneCmd réduit la taille du cache
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Supprime les sources les moins récemment utilisées",
+	Long: `Supprime les sources les moins récemment utilisées jusqu'à ce que le cache
+tienne dans la taille maximale (--max-size, sinon cache-max-size).`,
+	Args: cobra.NoArgs,
+	RunE: runCachePrune,
+}
+
+// cacheClearCmd vide le cache
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Vide le cache des sources",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+var cachePruneMaxSize string
+
+func runCacheList(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.List()
+	if err != nil {
+		return fmt.Errorf("lecture du cache: %w", err)
+	}
+
+	cmd.Printf("📦 Cache des sources (%s)\n", cache.Dir)
+	cmd.Printf("====================\n")
+
+	if len(entries) == 0 {
+		cmd.Printf("Cache vide.\n")
+		return nil
+	}
+
+	// En-têtes
+	cmd.Printf("%-8s %-40s %-12s %-20s %10s %-16s\n", "PROVIDER", "REPO", "COMMIT", "PATH", "SIZE", "LAST USED")
+	cmd.Printf("%s\n", strings.Repeat("-", 111))
+
+	var total int64
+	for _, entry := range entries {
+		path := entry.Path
+		if path == "" {
+			path = "/"
+		}
+		cmd.Printf("%-8s %-40s %-12.12s %-20s %10s %-16s\n",
+			entry.Provider,
+			entry.Repo,
+			entry.Commit,
+			path,
+			formatSize(entry.Size),
+			entry.LastUsed.Format("2006-01-02 15:04"))
+		total += entry.Size
+	}
+
+	cmd.Printf("\nTotal: %d entrées, %s (max: %s)\n", len(entries), formatSize(total), formatSize(cache.MaxSize))
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	maxSize := cache.MaxSize
+	if cachePruneMaxSize != "" {
+		if maxSize, err = parseSize(cachePruneMaxSize); err != nil {
+			return err
+		}
+	}
+
+	removed, err := cache.Prune(maxSize)
+	var freed int64
+	for _, entry := range removed {
+		freed += entry.Size
+	}
+	cmd.Printf("🧹 %d entrées supprimées, %s libérés\n", len(removed), formatSize(freed))
+
+	if err != nil {
+		return fmt.Errorf("nettoyage du cache: %w", err)
+	}
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	if err := cache.Clear(); err != nil {
+		return fmt.Errorf("suppression du cache: %w", err)
+	}
+
+	cmd.Printf("✅ Cache vidé (%s)\n", cache.Dir)
+	return nil
+}
+
+// openCache ouvre le cache configuré (cache-dir, cache-max-size)
+func openCache() (*generator.Cache, error) {
+	maxSize, err := cacheMaxSize()
+	if err != nil {
+		return nil, err
+	}
+	return generator.NewCache(viper.GetString("cache-dir"), maxSize)
+}
+
+// cacheMaxSize lit la taille maximale du cache configurée (ex: 500M, 2G)
+func cacheMaxSize() (int64, error) {
+	value := viper.GetString("cache-max-size")
+	if value == "" {
+		return generator.DefaultCacheMaxSize, nil
+	}
+	return parseSize(value)
+}
+
+// parseSize convertit une taille comme 500M ou 2G en octets
+func parseSize(value string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"K", 1 << 10},
+		{"M", 1 << 20},
+		{"G", 1 << 30},
+		{"T", 1 << 40},
+	}
+
+	number := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B")
+	factor := int64(1)
+	for _, unit := range units {
+		if trimmed, ok := strings.CutSuffix(number, unit.suffix); ok {
+			number, factor = trimmed, unit.factor
+			break
+		}
+	}
+
+	size, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("taille invalide: %q (ex: 500M, 2G)", value)
+	}
+	return int64(size * float64(factor)), nil
+}
+
+// formatSize affiche une taille en octets de façon lisible
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d o", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %co", float64(size)/float64(div), "KMGT"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	cachePruneCmd.Flags().StringVar(&cachePruneMaxSize, "max-size", "", "taille maximale à conserver (ex: 500M, 2G ; 0 pour tout supprimer)")
+}
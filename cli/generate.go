@@ -20,18 +20,64 @@ var (
 	waitInterval time.Duration
 	openResult   bool
 	npmPackages  []string
+	provider     string
+	ref          string
+	noCache      bool
+	force        bool
+	entry        string
+	decks        []string
+	allDecks     bool
+	concurrency  int
+	formats      []string
 )
 
 // generateCmd représente la commande generate
 var generateCmd = &cobra.Command{
-	Use:   "generate [URL_GITHUB]",
-	Short: "Génère une présentation Slidev depuis un dépôt GitHub",
-	Long: `Génère une présentation Slidev à partir d'un dépôt GitHub.
+	Use:   "generate [URL_DÉPÔT|CHEMIN]",
+	Short: "Génère une présentation Slidev depuis un dépôt git ou un répertoire local",
+	Long: `Génère une présentation Slidev à partir d'un dépôt git ou d'un répertoire local.
+
+Le fournisseur est déduit de l'URL : GitHub, GitLab (y compris auto-hébergé),
+Gitea/Forgejo (Codeberg...) ou, à défaut, n'importe quel serveur git en HTTPS
+ou SSH. --provider force le fournisseur pour une instance non reconnue.
+
+Un répertoire local (chemin ou URL file://) est uploadé directement, sans
+téléchargement : pratique pour tester une présentation en cours d'écriture.
+
+Un manifeste ocf.yaml à la racine des sources peut préciser le fichier
+principal, le thème, les packages npm, les fichiers à uploader, les formats
+de sortie... Les options de la ligne de commande restent prioritaires.
 
 Exemples:
   # Génération basique
   ocf-worker-cli generate https://github.com/ttamoud/presentation
 
+  # Depuis un répertoire local
+  ocf-worker-cli generate ./my-slides
+  ocf-worker-cli generate file:///home/me/talks/my-slides
+
+  # Depuis GitLab, Gitea/Forgejo ou un serveur git quelconque
+  ocf-worker-cli generate https://gitlab.com/group/subgroup/slides/-/tree/main/talk
+  ocf-worker-cli generate https://codeberg.org/user/slides/src/branch/main
+  ocf-worker-cli generate git@git.example.com:courses/intro.git --ref v2.0
+
+  # Instance GitLab auto-hébergée sur un domaine quelconque
+  ocf-worker-cli generate https://code.example.com/team/slides --provider gitlab
+
+  # Dépôt privé (jeton lu dans GITHUB_TOKEN, GITLAB_TOKEN... à défaut de --git-token)
+  ocf-worker-cli generate https://github.com/org/private-slides --git-token "$TOKEN"
+  ocf-worker-cli generate git@gitlab.com:org/private-slides.git --ssh-key ~/.ssh/id_ed25519
+
+  # Fichier principal autre que slides.md (détecté automatiquement sinon)
+  ocf-worker-cli generate https://github.com/user/repo --entry talk.md
+
+  # Dépôt multi-présentations (un chapitre par deck), 3 jobs en parallèle
+  ocf-worker-cli generate https://github.com/user/course --all-decks --concurrency 3
+  ocf-worker-cli generate ./course --deck chapter1/slides.md --deck chapter2/slides.md
+
+  # Archive TAR seulement, sans extraire le site
+  ocf-worker-cli generate https://github.com/user/repo --format tar
+
   # Avec sous-dossier spécifique
   ocf-worker-cli generate https://github.com/user/repo --subfolder presentations/my-talk
 
@@ -45,20 +91,42 @@ Exemples:
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
-	githubURL := args[0]
+	cacheSize, err := cacheMaxSize()
+	if err != nil {
+		return fmt.Errorf("configuration invalide: %w", err)
+	}
 
 	// Créer la configuration
 	config := &generator.Config{
-		GitHubURL:    githubURL,
 		APIBaseURL:   viper.GetString("api-url"),
-		OutputDir:    outputDir,
+		AuthToken:    viper.GetString("token"),
+		TLSCertFile:  viper.GetString("tls-cert"),
+		TLSKeyFile:   viper.GetString("tls-key"),
+		TLSCAFile:    viper.GetString("tls-ca"),
 		Subfolder:    subfolder,
 		Timeout:      viper.GetDuration("timeout"),
-		WaitTimeout:  waitTimeout,
 		WaitInterval: waitInterval,
 		Verbose:      viper.GetBool("verbose"),
 		NpmPackages:  npmPackages,
+		Force:        force,
+		Entry:        entry,
+		Decks:        decks,
+		AllDecks:     allDecks,
+		Formats:      formats,
+		Provider:     provider,
+		Ref:          ref,
+
+		GitUsername:      viper.GetString("git-username"),
+		GitToken:         viper.GetString("git-token"),
+		SSHKeyFile:       viper.GetString("ssh-key"),
+		SSHKeyPassphrase: viper.GetString("ssh-key-passphrase"),
+
+		NoCache:      noCache,
+		CacheDir:     viper.GetString("cache-dir"),
+		CacheMaxSize: cacheSize,
 	}
+	config.SetSource(args[0])
+	applyExplicitFlags(cmd, config)
 
 	// Valider la configuration
 	if err := config.Validate(); err != nil {
@@ -74,40 +142,99 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 
 	// Lancer la génération
 	result, err := gen.Generate(ctx)
-	if err != nil {
+	if err != nil && result == nil {
 		return fmt.Errorf("erreur de génération: %w", err)
 	}
 
 	// Afficher les résultats
-	cmd.Printf("🎉 Génération terminée avec succès!\n")
+	if err == nil {
+		cmd.Printf("🎉 Génération terminée avec succès!\n")
+	}
+	for _, deck := range result.Decks {
+		switch {
+		case deck.Err != nil:
+			cmd.Printf("❌ %s (%s): %v\n", deck.Name, deck.Entry, deck.Err)
+		case deck.Result.Reused:
+			cmd.Printf("♻️ %s (%s): résultats du job %s réutilisés\n", deck.Name, deck.Entry, deck.Result.JobID)
+		default:
+			cmd.Printf("✅ %s (%s): %s\n", deck.Name, deck.Entry, deck.Result.MainPath())
+		}
+	}
+	if result.Reused {
+		cmd.Printf("♻️ Sources inchangées: résultats du job %s réutilisés (--force pour régénérer)\n", result.JobID)
+	}
 	cmd.Printf("📁 Sortie: %s\n", result.OutputDir)
-	cmd.Printf("🌐 Présentation: %s\n", result.IndexPath)
+	if result.IndexPath != "" {
+		cmd.Printf("🌐 Présentation: %s\n", result.IndexPath)
+	}
+	for _, archive := range []string{result.ArchivePath, result.TarPath} {
+		if archive != "" {
+			cmd.Printf("📦 Archive: %s\n", archive)
+		}
+	}
 
 	// Ouvrir automatiquement si demandé
-	if openResult {
+	if openResult && result.IndexPath != "" {
 		if err := openInBrowser(result.IndexPath); err != nil {
 			cmd.Printf("⚠️ Impossible d'ouvrir automatiquement: %v\n", err)
 		}
 	}
 
+	if err != nil {
+		return fmt.Errorf("erreur de génération: %w", err)
+	}
 	return nil
 }
 
+// applyExplicitFlags reporte dans la configuration les options dont la
+// valeur par défaut cède la place à celle du manifeste ocf.yaml : elles ne
+// s'appliquent que si elles sont précisées sur la ligne de commande
+func applyExplicitFlags(cmd *cobra.Command, config *generator.Config) {
+	if cmd.Flags().Changed("output") {
+		config.OutputDir = outputDir
+	}
+	if cmd.Flags().Changed("wait-timeout") {
+		config.WaitTimeout = waitTimeout
+	}
+	if cmd.Flags().Changed("concurrency") {
+		config.Concurrency = concurrency
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
+	addGenerateFlags(generateCmd)
 
-	// Flags spécifiques à generate
-	generateCmd.Flags().StringVarP(&outputDir, "output", "o", "./output", "répertoire de sortie")
-	generateCmd.Flags().StringVar(&subfolder, "subfolder", "", "sous-dossier spécifique dans le dépôt")
-	generateCmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 15*time.Minute, "timeout d'attente de completion")
-	generateCmd.Flags().DurationVar(&waitInterval, "wait-interval", 5*time.Second, "intervalle de polling")
-	generateCmd.Flags().BoolVar(&openResult, "open", false, "ouvrir automatiquement la présentation")
-	generateCmd.Flags().StringArrayVar(&npmPackages, "npm-package", []string{}, "package npm à installer en plus (peut être utilisé plusieurs fois)")
+	viper.BindPFlag("git-token", generateCmd.Flags().Lookup("git-token"))
+	viper.BindPFlag("git-username", generateCmd.Flags().Lookup("git-username"))
+	viper.BindPFlag("ssh-key", generateCmd.Flags().Lookup("ssh-key"))
 
 	// Aliases
 	generateCmd.Aliases = []string{"gen", "g"}
 }
 
+// addGenerateFlags déclare les flags spécifiques à generate
+func addGenerateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputDir, "output", "o", "./output", "répertoire de sortie")
+	cmd.Flags().StringVar(&subfolder, "subfolder", "", "sous-dossier spécifique dans le dépôt")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 15*time.Minute, "timeout d'attente de completion")
+	cmd.Flags().DurationVar(&waitInterval, "wait-interval", 5*time.Second, "intervalle de polling")
+	cmd.Flags().BoolVar(&openResult, "open", false, "ouvrir automatiquement la présentation")
+	cmd.Flags().StringVar(&provider, "provider", "", "fournisseur du dépôt: github, gitlab, gitea ou git (défaut: déduit de l'URL)")
+	cmd.Flags().StringVar(&ref, "ref", "", "branche, tag ou commit à utiliser (défaut: celui de l'URL, sinon la branche par défaut)")
+	cmd.Flags().StringVar(&entry, "entry", "", "fichier principal de la présentation (défaut: détecté via package.json, slides.md...)")
+	cmd.Flags().StringArrayVar(&decks, "deck", []string{}, "fichier principal d'une présentation à générer (peut être utilisé plusieurs fois)")
+	cmd.Flags().BoolVar(&allDecks, "all-decks", false, "générer toutes les présentations détectées dans les sources")
+	cmd.Flags().IntVar(&concurrency, "concurrency", generator.DefaultConcurrency, "nombre de présentations générées en parallèle")
+	cmd.Flags().StringArrayVar(&formats, "format", []string{}, "résultat à télécharger: html, zip ou tar (peut être utilisé plusieurs fois ; défaut: html et zip)")
+	cmd.Flags().BoolVar(&force, "force", false, "régénérer même si un job précédent a les mêmes sources")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "ne pas utiliser le cache des sources")
+	cmd.Flags().String("git-token", "", "jeton d'accès aux dépôts privés (ou OCF_GIT_TOKEN, GITHUB_TOKEN, GITLAB_TOKEN...)")
+	cmd.Flags().String("git-username", "", "nom d'utilisateur associé au jeton (défaut selon le fournisseur)")
+	cmd.Flags().String("ssh-key", "", "clé privée SSH pour les dépôts en ssh:// (défaut: ssh-agent ; phrase de passe dans OCF_SSH_KEY_PASSPHRASE)")
+	cmd.Flags().StringArrayVar(&npmPackages, "npm-package", []string{}, "package npm à installer en plus de ceux déduits de package.json et du frontmatter (peut être utilisé plusieurs fois)")
+}
+
 func openInBrowser(path string) error {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ocf-worker-sdk/pkg/generator"
+)
+
+func TestApplyExplicitFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want generator.Config
+	}{
+		{
+			// Les valeurs par défaut laissent la place au manifeste
+			name: "defaults",
+			args: nil,
+			want: generator.Config{},
+		},
+		{
+			name: "explicit flags",
+			args: []string{"--output", "./out", "--wait-timeout", "20m", "--concurrency", "4"},
+			want: generator.Config{OutputDir: "./out", WaitTimeout: 20 * time.Minute, Concurrency: 4},
+		},
+		{
+			// Une valeur identique à la valeur par défaut, mais précisée, reste prioritaire
+			name: "explicit default value",
+			args: []string{"-o", "./output"},
+			want: generator.Config{OutputDir: "./output"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addGenerateFlags(cmd)
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			config := &generator.Config{}
+			applyExplicitFlags(cmd, config)
+			assert.Equal(t, &tt.want, config)
+		})
+	}
+}
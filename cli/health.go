@@ -102,6 +102,14 @@ func createClient() *ocfworker.Client {
 		opts = append(opts, ocfworker.WithAuth(token))
 	}
 
+	if certFile, keyFile := viper.GetString("tls-cert"), viper.GetString("tls-key"); certFile != "" || keyFile != "" {
+		opts = append(opts, ocfworker.WithClientCertificate(certFile, keyFile))
+	}
+
+	if caFile := viper.GetString("tls-ca"); caFile != "" {
+		opts = append(opts, ocfworker.WithRootCAs(caFile))
+	}
+
 	if viper.GetBool("verbose") {
 		opts = append(opts, ocfworker.WithLogger(generator.NewVerboseLogger()))
 	}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,6 +17,9 @@ var (
 	authToken string
 	timeout   time.Duration
 	verbose   bool
+	tlsCert   string
+	tlsKey    string
+	tlsCA     string
 )
 
 // rootCmd représente la commande de base quand appelée sans sous-commandes
@@ -49,12 +53,20 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&authToken, "token", "", "token d'authentification OCF Worker")
 	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 60*time.Second, "timeout des requêtes HTTP")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "mode verbeux")
+	rootCmd.PersistentFlags().StringVar(&tlsCert, "tls-cert", "", "certificat client PEM (mTLS)")
+	rootCmd.PersistentFlags().StringVar(&tlsKey, "tls-key", "", "clé privée PEM du certificat client (mTLS)")
+	rootCmd.PersistentFlags().StringVar(&tlsCA, "tls-ca", "", "autorités de certification PEM pour vérifier le worker")
+	rootCmd.PersistentFlags().String("cache-dir", "", "répertoire du cache des sources (défaut: cache utilisateur)")
 
 	// Liaison avec viper
 	viper.BindPFlag("api-url", rootCmd.PersistentFlags().Lookup("api-url"))
 	viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
 	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("tls-cert", rootCmd.PersistentFlags().Lookup("tls-cert"))
+	viper.BindPFlag("tls-key", rootCmd.PersistentFlags().Lookup("tls-key"))
+	viper.BindPFlag("tls-ca", rootCmd.PersistentFlags().Lookup("tls-ca"))
+	viper.BindPFlag("cache-dir", rootCmd.PersistentFlags().Lookup("cache-dir"))
 }
 
 // initConfig lit le fichier de configuration et les variables d'environnement si définies.
@@ -72,6 +84,7 @@ func initConfig() {
 
 	// Variables d'environnement
 	viper.SetEnvPrefix("OCF")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
 	// Lire le fichier de config
@@ -38,20 +38,25 @@
 package ocfworker
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
-	// Import des types existants
+
+	"github.com/google/uuid"
 )
 
 // Logger defines the interface for logging within the OCF Worker SDK.
 // Users can provide their own logger implementation to integrate with
 // their existing logging infrastructure.
 //
+// Fields are alternating key/value pairs using the LogKey* constants
+// (job_id, course_id, path, status, duration...). Tokens and presigned URLs
+// are redacted before reaching the logger. For log/slog, use WithSlogHandler.
+//
 // Example with logrus:
 //
 //	type LogrusAdapter struct {
@@ -85,7 +90,7 @@ type Logger interface {
 //	client := ocfworker.NewClient("http://localhost:8081",
 //		ocfworker.WithTimeout(30*time.Second),
 //	)
-//	defer client.Close() // If implemented
+//	defer client.Close(context.Background())
 type Client struct {
 	// httpClient is the underlying HTTP client used for all requests
 	httpClient *http.Client
@@ -93,6 +98,57 @@ type Client struct {
 	baseURL string
 	// logger handles all logging operations
 	logger Logger
+	// metrics receives client-side request and job metrics
+	metrics MetricsRecorder
+
+	// breakerConfig enables per-endpoint circuit breaking when set
+	breakerConfig *CircuitBreakerConfig
+	breakersMu    sync.Mutex
+	breakers      map[string]*circuitBreaker
+
+	// defaultLimits and classLimits configure rate limiting per endpoint class
+	defaultLimits rateLimitConfig
+	classLimits   map[EndpointClass]rateLimitConfig
+	limiters      map[EndpointClass]*classLimiter
+
+	// pool routes requests over several endpoints for multi-endpoint clients
+	pool *endpointPool
+
+	// cache keeps read-only responses for conditional requests and TTL caching
+	cacheTTL     time.Duration
+	cacheEntries int
+	cache        *responseCache
+
+	// apiVersion selects the /api/{version} route prefix; capabilities are
+	// discovered per endpoint base URL
+	apiVersion string
+	capsMu     sync.Mutex
+	caps       map[string]*capabilitiesState
+
+	// decoding controls how responses are checked against the models
+	decoding    decodingMode
+	driftWarned sync.Map
+
+	// responseLimits bound response sizes per endpoint class, decoders
+	// handle compressed responses
+	responseLimits map[EndpointClass]int64
+	maxLogSize     int64
+	decoders       map[string]ResponseDecoder
+
+	// authToken, the connection and the TLS settings are applied to the
+	// transport once all options are set; configErr reports an invalid configuration
+	authToken   string
+	socketPath  string
+	proxyURL    string
+	dialContext DialContextFunc
+	tlsConfig   *tls.Config
+	certFile    string
+	keyFile     string
+	rootCAFile  string
+	configErr   error
+
+	// lifecycle tracks calls in progress for Close
+	lifecycle *lifecycle
 
 	// Services provide access to different API endpoints through well-defined interfaces.
 	// This allows for easy testing and extensibility.
@@ -143,13 +199,9 @@ func WithTimeout(timeout time.Duration) Option {
 //	client := ocfworker.NewClient(baseURL, ocfworker.WithAuth("your-api-token"))
 func WithAuth(token string) Option {
 	return func(c *Client) {
-		// Ajouter l'auth aux headers par défaut
-		if c.httpClient.Transport == nil {
-			c.httpClient.Transport = &authTransport{
-				token: token,
-				base:  http.DefaultTransport,
-			}
-		}
+		// L'auth est ajoutée au transport une fois toutes les options appliquées,
+		// ce qui la rend compatible avec WithHTTPClient
+		c.authToken = token
 	}
 }
 
@@ -170,8 +222,9 @@ func WithLogger(logger Logger) Option {
 }
 
 // WithHTTPClient allows you to provide a custom HTTP client.
-// This is useful for advanced configuration like custom TLS settings,
-// proxy configuration, or connection pooling.
+// This is useful for advanced configuration like proxy configuration or
+// connection pooling. WithAuth, WithTimeout and the TLS options still apply
+// to it; the client is copied, so the provided one is not modified.
 //
 // Example:
 //
@@ -185,13 +238,16 @@ func WithLogger(logger Logger) Option {
 //	client := ocfworker.NewClient(baseURL, ocfworker.WithHTTPClient(httpClient))
 func WithHTTPClient(httpClient *http.Client) Option {
 	return func(c *Client) {
-		c.httpClient = httpClient
+		copied := *httpClient
+		c.httpClient = &copied
 	}
 }
 
 // NewClient creates a new OCF Worker SDK client with the given base URL and options.
 //
 // The baseURL should point to your OCF Worker instance (e.g., "http://localhost:8081").
+// A worker listening on a Unix domain socket is reached with a
+// "unix:///path/to/worker.sock" base URL.
 // Multiple options can be provided to customize the client behavior.
 //
 // Example:
@@ -208,14 +264,43 @@ func NewClient(baseURL string, opts ...Option) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		baseURL: baseURL,
-		logger:  &simpleLogger{},
+		baseURL:    baseURL,
+		logger:     &simpleLogger{},
+		metrics:    noopMetrics{},
+		apiVersion: APIVersionV1,
+		responseLimits: map[EndpointClass]int64{
+			EndpointClassLight: defaultMaxLightResponseSize,
+		},
+		maxLogSize: defaultMaxLogSize,
+		decoders:   defaultDecoders(),
+		lifecycle:  newLifecycle(),
+	}
+
+	if socketPath, httpURL, ok := parseUnixBaseURL(baseURL); ok {
+		client.socketPath = socketPath
+		client.baseURL = httpURL
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	if err := client.buildTransport(); err != nil {
+		client.configErr = fmt.Errorf("invalid client configuration: %w", err)
+		client.logger.Error("Invalid client configuration", LogKeyError, err)
+	}
+
+	client.limiters = map[EndpointClass]*classLimiter{
+		EndpointClassLight: newClassLimiter(client.limitsFor(EndpointClassLight)),
+		EndpointClassHeavy: newClassLimiter(client.limitsFor(EndpointClassHeavy)),
+	}
+
+	if client.cacheEntries > 0 {
+		client.cache = newResponseCache(client.cacheTTL, client.cacheEntries)
+	}
+
+	client.logger = &redactingLogger{next: client.logger}
+
 	client.Jobs = &JobsService{client: client}
 	client.Storage = &StorageService{client: client}
 	client.Worker = &WorkerService{client: client}
@@ -239,62 +324,188 @@ func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return t.base.RoundTrip(req)
 }
 
+// CloseIdleConnections closes the idle connections of the base transport, so
+// that Client.Close reaches them through the wrapper.
+func (t *authTransport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
 // simpleLogger is the default logger implementation that writes to Go's standard log package.
 // It's used when no custom logger is provided via WithLogger option.
 type simpleLogger struct{}
 
-// Debug logs debug messages with the [DEBUG] prefix
+// Debug logs debug messages with the [DEBUG] prefix, followed by key=value fields
 func (l *simpleLogger) Debug(msg string, fields ...interface{}) {
-	log.Printf("[DEBUG] %s %v", msg, fields)
+	log.Printf("[DEBUG] %s %s", msg, FormatFields(fields...))
 }
 
 // Info logs info messages with the [INFO] prefix
 func (l *simpleLogger) Info(msg string, fields ...interface{}) {
-	log.Printf("[INFO] %s %v", msg, fields)
+	log.Printf("[INFO] %s %s", msg, FormatFields(fields...))
 }
 
 // Warn logs warning messages with the [WARN] prefix
 func (l *simpleLogger) Warn(msg string, fields ...interface{}) {
-	log.Printf("[WARN] %s %v", msg, fields)
+	log.Printf("[WARN] %s %s", msg, FormatFields(fields...))
 }
 
 // Error logs error messages with the [ERROR] prefix
 func (l *simpleLogger) Error(msg string, fields ...interface{}) {
-	log.Printf("[ERROR] %s %v", msg, fields)
+	log.Printf("[ERROR] %s %s", msg, FormatFields(fields...))
 }
 
 // get performs a GET request to the specified API path.
 // It automatically prepends the base URL and API version prefix.
 //
-// This is an internal method used by service implementations.
+// This is an internal shortcut over doRaw.
 func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1"+path, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	c.logger.Debug("GET request", "url", req.URL.String())
-	return c.httpClient.Do(req)
+	return c.doRaw(ctx, &Request{Method: http.MethodGet, Path: path})
 }
 
 // post performs a POST request to the specified API path with JSON body.
 // It automatically prepends the base URL and API version prefix,
 // and sets the appropriate Content-Type header.
 //
-// This is an internal method used by service implementations.
+// This is an internal shortcut over doRaw.
 func (c *Client) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(body); err != nil {
-		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	return c.doRaw(ctx, &Request{Method: http.MethodPost, Path: path, Body: body})
+}
+
+// send executes a prepared request and records its metrics.
+// Every request issued by the services goes through send.
+//
+// send tags the request with an X-Request-ID header (taken from the context
+// when set with WithRequestID, generated otherwise) and wraps transport
+// failures in a RequestError carrying that ID.
+//
+// When the worker answers 429 Too Many Requests, the rate limiter of the
+// endpoint class is slowed down for the Retry-After delay and requests whose
+// body can be replayed are retried.
+//
+// GET requests on read-only endpoints go through the response cache, which
+// revalidates stored responses with If-None-Match and If-Modified-Since.
+//
+// Requests are rejected with ErrClientClosed once Close was called, unless
+// they are part of a call already in progress.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	requestID := req.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = RequestIDFromContext(req.Context())
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		req.Header.Set(RequestIDHeader, requestID)
 	}
+	if c.configErr != nil {
+		return nil, &RequestError{Method: req.Method, Path: req.URL.Path, RequestID: requestID, Err: c.configErr}
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", c.acceptEncoding())
+	}
+
+	return c.sendTracked(req, func(req *http.Request) (*http.Response, error) {
+		return c.dispatch(req, requestID)
+	})
+}
+
+// sendWithRetries executes a request on its current URL, retrying it when
+// the worker answers 429 Too Many Requests.
+func (c *Client) sendWithRetries(req *http.Request, requestID string) (*http.Response, error) {
+	endpoint := endpointLabel(req.URL.Path)
+	class := classifyEndpoint(req.Method, endpoint)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.sendOnce(req, endpoint, class, requestID)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		c.limiters[class].throttle(retryAfter)
+
+		if attempt >= maxThrottleRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, &RequestError{Method: req.Method, Path: req.URL.Path, RequestID: requestID, Err: err}
+			}
+			req.Body = body
+		}
+
+		c.metrics.IncRetry(endpoint)
+		c.logger.Warn("Throttled by worker, retrying", LogKeyMethod, req.Method, LogKeyPath, req.URL.Path,
+			LogKeyRequestID, requestID, "retry_after", retryAfter)
+	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1"+path, &buf)
+// sendOnce performs a single attempt of a request: it checks the circuit
+// breaker, waits for the rate limiter and concurrency budget of the endpoint
+// class, then executes the request.
+func (c *Client) sendOnce(req *http.Request, endpoint string, class EndpointClass, requestID string) (*http.Response, error) {
+	// The breaker is checked first: its probe is itself a request and must
+	// not wait for a concurrency slot held by this one.
+	breaker := c.circuitBreakerFor(req.Context(), req.URL)
+	if breaker != nil {
+		if err := breaker.allow(req.Context()); err != nil {
+			c.logger.Warn("Request rejected by circuit breaker",
+				LogKeyMethod, req.Method, LogKeyPath, req.URL.Path, LogKeyRequestID, requestID)
+			if openErr, ok := err.(*CircuitOpenError); ok {
+				openErr.RequestID = requestID
+			}
+			return nil, err
+		}
+	}
+
+	limiter := c.limiters[class]
+	release, err := limiter.acquire(req.Context())
 	if err != nil {
-		return nil, err
+		return nil, &RequestError{Method: req.Method, Path: req.URL.Path, RequestID: requestID, Err: err}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = &countingReadCloser{
+			ReadCloser: req.Body,
+			count:      func(n int64) { c.metrics.AddUploadBytes(endpoint, n) },
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	duration := time.Since(start)
+
+	if breaker != nil {
+		breaker.record(isCircuitFailure(resp, err))
+	}
+	if err != nil {
+		release()
+		c.metrics.ObserveRequest(req.Method, endpoint, 0, duration)
+		c.logger.Debug("HTTP request failed", LogKeyMethod, req.Method, LogKeyPath, req.URL.Path,
+			LogKeyRequestID, requestID, LogKeyDuration, duration, LogKeyError, err)
+		return nil, &RequestError{Method: req.Method, Path: req.URL.Path, RequestID: requestID, Err: err}
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		limiter.recover()
+	}
+	c.metrics.ObserveRequest(req.Method, endpoint, resp.StatusCode, duration)
+	c.logger.Debug("HTTP request", LogKeyMethod, req.Method, LogKeyPath, req.URL.Path,
+		LogKeyRequestID, responseRequestID(resp), LogKeyStatus, resp.StatusCode, LogKeyDuration, duration)
+
+	// The concurrency slot is held until the caller is done with the body,
+	// so that streamed downloads count as in flight.
+	resp.Body = &releasingReadCloser{
+		ReadCloser: &countingReadCloser{
+			ReadCloser: resp.Body,
+			count:      func(n int64) { c.metrics.AddDownloadBytes(endpoint, n) },
+		},
+		release: release,
+	}
+	c.wrapResponseBody(resp, endpoint, class)
 
-	c.logger.Debug("POST request", "url", req.URL.String())
-	return c.httpClient.Do(req)
+	return resp, nil
 }
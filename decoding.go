@@ -0,0 +1,309 @@
+package ocfworker
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+// decodingMode controls how response bodies are checked against the SDK models.
+type decodingMode int
+
+const (
+	// decodingSilent ignores unknown and missing fields (default)
+	decodingSilent decodingMode = iota
+	// decodingLenient logs schema drift once per endpoint
+	decodingLenient
+	// decodingStrict rejects responses that don't match the models
+	decodingStrict
+)
+
+// requiredFields lists the JSON fields every worker response of a type must carry.
+var requiredFields = map[reflect.Type][]string{
+	reflect.TypeOf(models.JobResponse{}):           {"id", "course_id", "status"},
+	reflect.TypeOf(models.JobListResponse{}):       {"jobs"},
+	reflect.TypeOf(models.FileListResponse{}):      {"files"},
+	reflect.TypeOf(models.FileUploadResponse{}):    {"count"},
+	reflect.TypeOf(models.HealthResponse{}):        {"status"},
+	reflect.TypeOf(models.WorkerHealthResponse{}):  {"status", "worker_pool"},
+	reflect.TypeOf(models.WorkerStatsResponse{}):   {"worker_pool"},
+	reflect.TypeOf(models.WorkspaceListResponse{}): {"workspaces"},
+	reflect.TypeOf(models.WorkspaceInfoResponse{}): {"workspace"},
+	reflect.TypeOf(models.StorageInfo{}):           {"storage_type", "status"},
+	reflect.TypeOf(Capabilities{}):                 {"version"},
+}
+
+// WithStrictDecoding makes the client reject responses that don't match the
+// SDK models: unknown fields (at any depth) and missing required fields
+// (e.g. the id and status of a JobResponse) fail the call with an error
+// wrapping a SchemaMismatchError or the JSON decoding error.
+//
+// Use it in integration tests to catch schema drift between the SDK and the
+// worker early.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithStrictDecoding())
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.decoding = decodingStrict
+	}
+}
+
+// WithLenientDecoding makes the client decode responses as usual but log a
+// warning, once per endpoint, when a response has unknown fields or lacks
+// required ones. Nested objects and arrays are checked like in strict mode.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithLenientDecoding())
+func WithLenientDecoding() Option {
+	return func(c *Client) {
+		c.decoding = decodingLenient
+	}
+}
+
+// SchemaMismatchError reports differences between a response and the SDK model.
+//
+// Example usage:
+//
+//	var mismatch *ocfworker.SchemaMismatchError
+//	if errors.As(err, &mismatch) {
+//		log.Printf("worker API changed on %s: unknown %v, missing %v",
+//			mismatch.Endpoint, mismatch.UnknownFields, mismatch.MissingFields)
+//	}
+type SchemaMismatchError struct {
+	// Endpoint is the route template of the request (e.g. "/jobs/:id")
+	Endpoint string
+
+	// UnknownFields lists fields absent from the model, nested ones as paths
+	// (e.g. "worker_pool.gpu_count", "jobs[].priority")
+	UnknownFields []string
+
+	// MissingFields lists required fields absent from the response, nested
+	// ones as paths (e.g. "jobs[].status")
+	MissingFields []string
+}
+
+// Error implements the error interface.
+func (e *SchemaMismatchError) Error() string {
+	var parts []string
+	if len(e.UnknownFields) > 0 {
+		parts = append(parts, "unknown fields: "+strings.Join(e.UnknownFields, ", "))
+	}
+	if len(e.MissingFields) > 0 {
+		parts = append(parts, "missing required fields: "+strings.Join(e.MissingFields, ", "))
+	}
+	return fmt.Sprintf("response schema mismatch on %s (%s)", e.Endpoint, strings.Join(parts, "; "))
+}
+
+// decodeResponse decodes a JSON response body into v.
+// Decoding failures are returned as a RequestError carrying the request ID.
+func (c *Client) decodeResponse(resp *http.Response, v interface{}) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return newResponseError(resp, fmt.Errorf("failed to read response body: %w", err))
+	}
+	return c.decodeBytes(resp, data, v)
+}
+
+// decodeBytes decodes an already read response body into v, checking it
+// against the model according to the decoding mode.
+func (c *Client) decodeBytes(resp *http.Response, data []byte, v interface{}) error {
+	if c.decoding != decodingSilent {
+		if mismatch := checkSchema(data, v); mismatch != nil {
+			if resp.Request != nil {
+				mismatch.Endpoint = endpointLabel(resp.Request.URL.Path)
+			}
+			if c.decoding == decodingStrict {
+				return newResponseError(resp, mismatch)
+			}
+			c.warnDrift(mismatch)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if c.decoding == decodingStrict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return newResponseError(resp, fmt.Errorf("failed to decode response: %w", err))
+	}
+	return nil
+}
+
+// warnDrift logs a schema mismatch, once per endpoint.
+func (c *Client) warnDrift(mismatch *SchemaMismatchError) {
+	if _, logged := c.driftWarned.LoadOrStore(mismatch.Endpoint, true); logged {
+		return
+	}
+	c.logger.Warn("Response schema drift detected", LogKeyPath, mismatch.Endpoint,
+		"unknown_fields", strings.Join(mismatch.UnknownFields, ","),
+		"missing_fields", strings.Join(mismatch.MissingFields, ","))
+}
+
+// checkSchema compares the fields of a JSON object with the struct pointed
+// to by v, walking nested objects, arrays and maps the same way as strict
+// decoding. It returns nil when they match or when v is not a struct.
+func checkSchema(data []byte, v interface{}) *SchemaMismatchError {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return nil
+	}
+
+	unknown := make(map[string]bool)
+	missing := make(map[string]bool)
+	walkSchema(value, t.Elem(), "", unknown, missing)
+	if len(unknown) == 0 && len(missing) == 0 {
+		return nil
+	}
+
+	mismatch := &SchemaMismatchError{}
+	for field := range unknown {
+		mismatch.UnknownFields = append(mismatch.UnknownFields, field)
+	}
+	slices.Sort(mismatch.UnknownFields)
+	// Top-level required fields come first, in the order of requiredFields
+	for _, field := range requiredFields[t.Elem()] {
+		if missing[field] {
+			mismatch.MissingFields = append(mismatch.MissingFields, field)
+			delete(missing, field)
+		}
+	}
+	nested := make([]string, 0, len(missing))
+	for field := range missing {
+		nested = append(nested, field)
+	}
+	slices.Sort(nested)
+	mismatch.MissingFields = append(mismatch.MissingFields, nested...)
+	return mismatch
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// walkSchema records in unknown and missing the fields of value, a decoded
+// JSON value, that don't match type t. path is the location of value in the
+// response (e.g. "jobs[]").
+func walkSchema(value interface{}, t reflect.Type, path string, unknown, missing map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Types decoding themselves (time.Time, uuid.UUID...) have no fields to check
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		// encoding/json matches field names case-insensitively
+		present := make(map[string]bool, len(object))
+		for key, fieldValue := range object {
+			if fieldValue != nil {
+				present[strings.ToLower(key)] = true
+			}
+		}
+
+		fields := jsonFields(t)
+		for key, fieldValue := range object {
+			fieldType, known := fields[strings.ToLower(key)]
+			if !known {
+				unknown[path+key] = true
+				continue
+			}
+			walkSchema(fieldValue, fieldType, path+key+".", unknown, missing)
+		}
+		for _, field := range requiredFields[t] {
+			if !present[field] {
+				missing[path+field] = true
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		itemPath := strings.TrimSuffix(path, ".") + "[]."
+		for _, item := range items {
+			walkSchema(item, t.Elem(), itemPath, unknown, missing)
+		}
+
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		entryPath := strings.TrimSuffix(path, ".") + "{}."
+		for _, entry := range entries {
+			walkSchema(entry, t.Elem(), entryPath, unknown, missing)
+		}
+	}
+}
+
+var fieldsCache sync.Map // reflect.Type -> map[string]reflect.Type
+
+// jsonFields returns the types of the fields of a struct type, by lowercased
+// JSON name.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if cached, ok := fieldsCache.Load(t); ok {
+		return cached.(map[string]reflect.Type)
+	}
+
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedType := range jsonFields(embedded) {
+					fields[embeddedName] = embeddedType
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+
+	fieldsCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,171 @@
+package ocfworker
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New().String()
+	courseID := uuid.New().String()
+
+	t.Run("valid response", func(t *testing.T) {
+		server.On("GET", "/api/v1/jobs/"+jobID, func(w http.ResponseWriter, r *http.Request) {
+			RespondJSON(w, http.StatusOK, NewJobResponse().Build())
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient(WithStrictDecoding()).Jobs.Get(ctx, jobID)
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		server.On("GET", "/api/v1/jobs/"+jobID, func(w http.ResponseWriter, r *http.Request) {
+			RespondJSON(w, http.StatusOK, map[string]interface{}{
+				"id": jobID, "course_id": courseID, "status": "pending", "priority": "high",
+			})
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient(WithStrictDecoding()).Jobs.Get(ctx, jobID)
+
+		var mismatch *SchemaMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, "/jobs/:id", mismatch.Endpoint)
+		assert.Equal(t, []string{"priority"}, mismatch.UnknownFields)
+		assert.Empty(t, mismatch.MissingFields)
+		assert.NotEmpty(t, RequestIDFromError(err))
+
+		// The default mode ignores it
+		_, err = server.TestClient().Jobs.Get(ctx, jobID)
+		require.NoError(t, err)
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		server.On("GET", "/api/v1/storage/courses/"+courseID+"/results", func(w http.ResponseWriter, r *http.Request) {
+			RespondJSON(w, http.StatusOK, map[string]interface{}{"course_id": courseID, "files": nil})
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient(WithStrictDecoding()).Storage.ListResults(ctx, courseID)
+
+		var mismatch *SchemaMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, []string{"files"}, mismatch.MissingFields)
+		assert.Contains(t, err.Error(), "missing required fields: files")
+	})
+
+	t.Run("nested unknown field", func(t *testing.T) {
+		server.On("GET", "/api/v1/worker/stats", func(w http.ResponseWriter, r *http.Request) {
+			RespondJSON(w, http.StatusOK, map[string]interface{}{
+				"worker_pool": map[string]interface{}{"worker_count": 3, "gpu_count": 1},
+			})
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient(WithStrictDecoding()).Worker.Stats(ctx)
+
+		require.Error(t, err)
+		var mismatch *SchemaMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, []string{"worker_pool.gpu_count"}, mismatch.UnknownFields)
+	})
+}
+
+func TestLenientDecoding(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New().String()
+	server.On("GET", "/api/v1/jobs/"+jobID, func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"id": jobID, "status": "pending", "priority": "high",
+		})
+	})
+
+	logger := &recordingLogger{}
+	client := server.TestClient(WithLenientDecoding(), WithLogger(logger))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		job, err := client.Jobs.Get(ctx, jobID)
+		require.NoError(t, err)
+		assert.Equal(t, jobID, job.ID.String())
+	}
+
+	var warnings []recordedEntry
+	for _, entry := range logger.entries {
+		if entry.msg == "Response schema drift detected" {
+			warnings = append(warnings, entry)
+		}
+	}
+	require.Len(t, warnings, 1, "drift is reported once per endpoint")
+	assert.Equal(t, []interface{}{
+		LogKeyPath, "/jobs/:id", "unknown_fields", "priority", "missing_fields", "course_id",
+	}, warnings[0].fields)
+}
+
+func TestLenientDecoding_NestedDrift(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"count": 2,
+			"jobs": []interface{}{
+				map[string]interface{}{"id": uuid.New(), "course_id": uuid.New(), "status": "pending", "priority": "high"},
+				map[string]interface{}{"id": uuid.New(), "status": "completed", "created_at": "2024-01-01T00:00:00Z"},
+			},
+		})
+	})
+
+	logger := &recordingLogger{}
+	client := server.TestClient(WithLenientDecoding(), WithLogger(logger))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	jobs, err := client.Jobs.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, jobs.Jobs, 2)
+
+	var warnings []recordedEntry
+	for _, entry := range logger.entries {
+		if entry.msg == "Response schema drift detected" {
+			warnings = append(warnings, entry)
+		}
+	}
+	require.Len(t, warnings, 1)
+	assert.Equal(t, []interface{}{
+		LogKeyPath, "/jobs", "unknown_fields", "jobs[].priority", "missing_fields", "jobs[].course_id",
+	}, warnings[0].fields)
+}
+
+func TestCheckSchema(t *testing.T) {
+	var health models.WorkerHealthResponse
+	mismatch := checkSchema([]byte(`{"worker_pool": {"worker_count": 3, "gpu_count": 1}, "extra": true}`), &health)
+	require.NotNil(t, mismatch)
+	assert.Equal(t, []string{"extra", "worker_pool.gpu_count"}, mismatch.UnknownFields)
+	assert.Equal(t, []string{"status"}, mismatch.MissingFields)
+
+	// Free-form values (metadata) and self-decoding types (times) are not walked
+	var job models.JobResponse
+	assert.Nil(t, checkSchema([]byte(`{"id": "`+uuid.NewString()+`", "course_id": "`+uuid.NewString()+
+		`", "status": "pending", "metadata": {"any": {"thing": 1}}, "created_at": "2024-01-01T00:00:00Z"}`), &job))
+}
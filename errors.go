@@ -2,6 +2,7 @@ package ocfworker
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -144,6 +145,9 @@ func (v *ValidationError) Error() string {
 type JobNotFoundError struct {
 	// JobID is the ID of the job that was not found
 	JobID string
+
+	// RequestID identifies the request that reported the missing job
+	RequestID string
 }
 
 // Error implements the error interface and returns a descriptive error message
@@ -158,6 +162,95 @@ func (e *JobNotFoundError) IsTemporary() bool {
 	return false
 }
 
+// RequestError is returned when a request could not be completed or its
+// response could not be read, as opposed to the API answering with an error.
+// It carries the request ID so that the failure can be correlated with the
+// worker's logs, and wraps the underlying error.
+//
+// Example usage:
+//
+//	_, err := client.Jobs.Get(ctx, jobID)
+//	var reqErr *ocfworker.RequestError
+//	if errors.As(err, &reqErr) {
+//		log.Printf("request %s failed: %v", reqErr.RequestID, reqErr.Err)
+//	}
+type RequestError struct {
+	// Method is the HTTP method of the failed request
+	Method string
+
+	// Path is the URL path of the failed request
+	Path string
+
+	// RequestID is the X-Request-ID of the failed request
+	RequestID string
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface and appends the request ID to the underlying error.
+func (e *RequestError) Error() string {
+	if e.RequestID == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (request_id: %s)", e.Err, e.RequestID)
+}
+
+// Unwrap returns the underlying error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// newResponseError wraps an error related to a received response into a RequestError.
+func newResponseError(resp *http.Response, err error) error {
+	reqErr := &RequestError{
+		RequestID: responseRequestID(resp),
+		Err:       err,
+	}
+	if resp.Request != nil {
+		reqErr.Method = resp.Request.Method
+		reqErr.Path = resp.Request.URL.Path
+	}
+	return reqErr
+}
+
+// RequestIDFromError returns the request ID attached to an error returned by the SDK,
+// or an empty string if the error doesn't carry one.
+//
+// The ID is carried by *APIError, *JobNotFoundError, *RequestError and
+// *CircuitOpenError, i.e. by every error of a request that was attempted.
+// Errors raised before a request is built, such as *UnsupportedFeatureError
+// or validation errors, have no request ID.
+//
+// Example:
+//
+//	if _, err := client.Jobs.Create(ctx, req); err != nil {
+//		log.Printf("job creation failed (request %s): %v", ocfworker.RequestIDFromError(err), err)
+//	}
+func RequestIDFromError(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RequestID
+	}
+
+	var notFoundErr *JobNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return notFoundErr.RequestID
+	}
+
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.RequestID
+	}
+
+	var openErr *CircuitOpenError
+	if errors.As(err, &openErr) {
+		return openErr.RequestID
+	}
+
+	return ""
+}
+
 // parseAPIError extracts structured error information from an HTTP response.
 // It attempts to parse the response body as JSON and create an appropriate error type.
 //
@@ -169,18 +262,24 @@ func (e *JobNotFoundError) IsTemporary() bool {
 // This is an internal function used by service implementations to convert
 // HTTP error responses into typed Go errors.
 func parseAPIError(resp *http.Response) error {
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
 	if err != nil {
 		return &APIError{
 			StatusCode: resp.StatusCode,
 			Message:    string(body),
+			RequestID:  responseRequestID(resp),
 		}
 	}
 
+	requestID := responseRequestID(resp)
+
 	// Attempt to parse as a complete APIError structure first
 	var apiErr APIError
 	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Message != "" {
 		apiErr.StatusCode = resp.StatusCode
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = requestID
+		}
 		return &apiErr
 	}
 
@@ -199,6 +298,7 @@ func parseAPIError(resp *http.Response) error {
 			return &APIError{
 				StatusCode: resp.StatusCode,
 				Message:    string(body),
+				RequestID:  requestID,
 			}
 		}
 	}
@@ -207,6 +307,7 @@ func parseAPIError(resp *http.Response) error {
 	return &APIError{
 		StatusCode: resp.StatusCode,
 		Message:    resp.Status,
+		RequestID:  requestID,
 	}
 }
 
@@ -1,11 +1,13 @@
 package ocfworker
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -210,6 +212,81 @@ func TestParseAPIErrorWithTestServer(t *testing.T) {
 	})
 }
 
+func TestRequestIDPropagation(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	t.Run("generated request ID attached to API errors", func(t *testing.T) {
+		var sentID string
+		server.On("GET", "/api/v1/jobs/req-id-api-error", func(w http.ResponseWriter, r *http.Request) {
+			sentID = r.Header.Get(RequestIDHeader)
+			RespondError(w, http.StatusInternalServerError, "boom")
+		})
+
+		client := server.TestClient()
+		ctx, _ := TestContext()
+
+		_, err := client.Jobs.Get(ctx, "req-id-api-error")
+		require.Error(t, err)
+
+		assert.NotEmpty(t, sentID)
+		assert.Equal(t, sentID, RequestIDFromError(err))
+	})
+
+	t.Run("request ID from context and response header", func(t *testing.T) {
+		var sentID string
+		server.On("GET", "/api/v1/jobs/req-id-not-found", func(w http.ResponseWriter, r *http.Request) {
+			sentID = r.Header.Get(RequestIDHeader)
+			w.Header().Set(RequestIDHeader, "server-"+sentID)
+			RespondError(w, http.StatusNotFound, "not found")
+		})
+
+		client := server.TestClient()
+		ctx, _ := TestContext()
+		ctx = WithRequestID(ctx, "caller-id")
+
+		_, err := client.Jobs.Get(ctx, "req-id-not-found")
+		require.Error(t, err)
+
+		assert.Equal(t, "caller-id", sentID)
+		assert.IsType(t, &JobNotFoundError{}, err)
+		assert.Equal(t, "server-caller-id", RequestIDFromError(err))
+	})
+
+	t.Run("decode errors carry request ID", func(t *testing.T) {
+		server.On("GET", "/api/v1/storage/info", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("not json"))
+		})
+
+		client := server.TestClient()
+		ctx, _ := TestContext()
+
+		_, err := client.Storage.GetStorageInfo(ctx)
+		require.Error(t, err)
+
+		var reqErr *RequestError
+		require.ErrorAs(t, err, &reqErr)
+		assert.NotEmpty(t, reqErr.RequestID)
+		assert.Contains(t, err.Error(), "failed to decode response")
+		assert.Contains(t, err.Error(), reqErr.RequestID)
+	})
+
+	t.Run("transport errors carry request ID", func(t *testing.T) {
+		client := NewClient("http://127.0.0.1:1", WithTimeout(time.Second))
+		ctx := WithRequestID(context.Background(), "transport-id")
+
+		_, err := client.Health.Check(ctx)
+		require.Error(t, err)
+		assert.Equal(t, "transport-id", RequestIDFromError(err))
+	})
+
+	t.Run("errors without request ID", func(t *testing.T) {
+		assert.Empty(t, RequestIDFromError(nil))
+		assert.Empty(t, RequestIDFromError(assert.AnError))
+	})
+}
+
 func TestValidationError(t *testing.T) {
 	valErr := ValidationError{
 		Field:   "email",
@@ -53,7 +53,7 @@ func TestHealthService_Check(t *testing.T) {
 
 	t.Run("invalid response", func(t *testing.T) {
 		server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusBadRequest)
+			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("invalid json"))
 		})
 
@@ -66,6 +66,36 @@ func TestHealthService_Check(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to decode response")
 	})
 
+	t.Run("error status with invalid body", func(t *testing.T) {
+		server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid json"))
+		})
+
+		client := server.TestClient()
+		ctx, _ := TestContext()
+
+		_, err := client.Health.Check(ctx)
+
+		require.Error(t, err)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	})
+
+	t.Run("error status with strict decoding", func(t *testing.T) {
+		server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+			RespondError(w, http.StatusUnauthorized, "missing token")
+		})
+
+		client := server.TestClient(WithStrictDecoding())
+		ctx, _ := TestContext()
+
+		_, err := client.Health.Check(ctx)
+
+		AssertAPIError(t, err, http.StatusUnauthorized, "missing token")
+	})
+
 	t.Run("unexpected status code", func(t *testing.T) {
 		apiErr := NewAPIError().
 			WithStatus(http.StatusBadRequest).
@@ -141,6 +171,20 @@ func TestWorkerService_Health(t *testing.T) {
 		assert.Equal(t, "degraded", health.Status)
 		assert.Equal(t, 1, health.WorkerPool.ActiveWorkers)
 	})
+
+	t.Run("error status with strict decoding", func(t *testing.T) {
+		server.On("GET", "/api/v1/worker/health", func(w http.ResponseWriter, r *http.Request) {
+			RespondError(w, http.StatusInternalServerError, "pool unavailable")
+		})
+
+		client := server.TestClient(WithStrictDecoding())
+		ctx, _ := TestContext()
+
+		health, err := client.Worker.Health(ctx)
+
+		assert.Nil(t, health)
+		AssertAPIError(t, err, http.StatusInternalServerError, "pool unavailable")
+	})
 }
 
 func TestWorkerService_ListWorkspaces(t *testing.T) {
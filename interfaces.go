@@ -93,10 +93,15 @@ type StorageServiceInterface interface {
 	// UploadSourcesStream uploads source files using streaming I/O.
 	// This method is more memory-efficient for large files as it doesn't
 	// require loading the entire file content into memory.
+	//
+	// Each part is sent with the upload's ContentType. When the Size of every
+	// upload is known, the request carries a Content-Length; otherwise it is
+	// sent chunked. Uploads using Open are opened only while their part is sent.
 	UploadSourcesStream(ctx context.Context, jobID string, uploads []StreamUpload) (*models.FileUploadResponse, error)
 
 	// UploadSourceFiles is a convenience method that uploads files directly
-	// from the filesystem. It handles opening files and setting up streaming uploads.
+	// from the filesystem. It handles opening files and setting up streaming uploads;
+	// each file is closed as soon as it has been sent.
 	//
 	// Example:
 	//	filePaths := []string{"./slides.md", "./images/logo.png"}
@@ -128,6 +133,9 @@ type StorageServiceInterface interface {
 	// This includes compilation logs, error messages, and debug information.
 	//
 	// Logs are particularly useful for debugging failed jobs.
+	//
+	// Logs larger than the limit set with WithMaxLogSize are truncated:
+	// their end is kept and prefixed with TruncatedLogsMarker.
 	GetLogs(ctx context.Context, jobID string) (string, error)
 
 	// GetStorageInfo returns storage quota and usage information.
@@ -2,7 +2,6 @@ package ocfworker
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -25,48 +24,27 @@ type WaitOptions struct {
 
 // Create crée un nouveau job
 func (s *JobsService) Create(ctx context.Context, req *models.GenerationRequest) (*models.JobResponse, error) {
-	s.client.logger.Info("Creating job", "job_id", req.JobID, "course_id", req.CourseID)
+	s.client.logger.Info("Creating job", LogKeyJobID, req.JobID, LogKeyCourseID, req.CourseID)
 
-	resp, err := s.client.post(ctx, "/generate", req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		return nil, parseAPIError(resp)
-	}
-
-	var job models.JobResponse
-	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+	// Les clients multi-instances routent le job vers l'instance de ses sources
+	ctx = withJobRouting(ctx, req.JobID.String(), req.CourseID.String())
 
-	return &job, nil
+	return do[models.JobResponse](ctx, s.client, &Request{
+		Method:         http.MethodPost,
+		Path:           "/generate",
+		Body:           req,
+		ExpectedStatus: []int{http.StatusCreated},
+	})
 }
 
 // Get récupère le statut d'un job
 func (s *JobsService) Get(ctx context.Context, jobID string) (*models.JobResponse, error) {
-	resp, err := s.client.get(ctx, fmt.Sprintf("/jobs/%s", jobID))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, &JobNotFoundError{JobID: jobID}
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var job models.JobResponse
-	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &job, nil
+	return do[models.JobResponse](ctx, s.client, &Request{
+		Path: fmt.Sprintf("/jobs/%s", jobID),
+		notFound: func(resp *http.Response) error {
+			return &JobNotFoundError{JobID: jobID, RequestID: responseRequestID(resp)}
+		},
+	})
 }
 
 // List liste les jobs avec pagination et filtres
@@ -88,27 +66,7 @@ func (s *JobsService) List(ctx context.Context, opts *ListJobsOptions) (*models.
 		}
 	}
 
-	path := "/jobs"
-	if len(params) > 0 {
-		path += "?" + params.Encode()
-	}
-
-	resp, err := s.client.get(ctx, path)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var jobList models.JobListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&jobList); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &jobList, nil
+	return do[models.JobListResponse](ctx, s.client, &Request{Path: "/jobs", Query: params})
 }
 
 // CreateAndWait crée un job et attend sa completion (polling automatique)
@@ -139,6 +97,13 @@ func (s *JobsService) WaitForCompletion(ctx context.Context, jobID string, opts
 		}
 	}
 
+	// L'attente compte comme un appel en cours pour Close
+	ctx, end, err := s.client.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
 	// Créer un contexte avec timeout pour le polling global
 	waitCtx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
 	defer cancel()
@@ -146,32 +111,40 @@ func (s *JobsService) WaitForCompletion(ctx context.Context, jobID string, opts
 	ticker := time.NewTicker(opts.Interval)
 	defer ticker.Stop()
 
-	s.client.logger.Info("Waiting for job completion", "job_id", jobID)
+	s.client.logger.Info("Waiting for job completion", LogKeyJobID, jobID)
+
+	start := time.Now()
 
 	for {
 		select {
 		case <-waitCtx.Done():
+			s.client.metrics.ObserveJobWait(waitStatusTimeout, time.Since(start))
 			return nil, fmt.Errorf("timeout waiting for job completion: %w", waitCtx.Err())
 		case <-ctx.Done():
+			s.client.metrics.ObserveJobWait(waitStatusCanceled, time.Since(start))
 			return nil, fmt.Errorf("context canceled while waiting for job completion: %w", ctx.Err())
 		case <-ticker.C:
 			job, err := s.Get(ctx, jobID)
 			if err != nil {
 				if isTimeoutError(err) {
-					s.client.logger.Debug("Request timeout, retrying", "job_id", jobID)
+					s.client.logger.Debug("Request timeout, retrying", LogKeyJobID, jobID)
+					s.client.metrics.IncRetry("/jobs/:id")
 					continue
 				}
 				return nil, err
 			}
 
-			s.client.logger.Debug("Job status check", "job_id", jobID, "status", job.Status)
+			s.client.logger.Debug("Job status check", LogKeyJobID, jobID, LogKeyStatus, job.Status)
 
 			switch job.Status {
 			case models.StatusCompleted:
-				s.client.logger.Info("Job completed successfully", "job_id", jobID)
+				s.client.logger.Info("Job completed successfully", LogKeyJobID, jobID, LogKeyDuration, time.Since(start))
+				s.client.metrics.ObserveJobWait(string(job.Status), time.Since(start))
 				return job, nil
 			case models.StatusFailed, models.StatusTimeout:
-				s.client.logger.Error("Job failed", "job_id", jobID, "status", job.Status, "error", job.Error)
+				s.client.logger.Error("Job failed",
+					LogKeyJobID, jobID, LogKeyStatus, job.Status, LogKeyDuration, time.Since(start), LogKeyError, job.Error)
+				s.client.metrics.ObserveJobWait(string(job.Status), time.Since(start))
 				return job, fmt.Errorf("job failed with status %s: %s", job.Status, job.Error)
 			}
 		}
@@ -0,0 +1,136 @@
+package ocfworker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is returned by calls made after Client.Close.
+var ErrClientClosed = errors.New("client is closed")
+
+// operationKey marks the context of a call in progress on a client, so that
+// the requests it issues itself (polling, health probes...) are still
+// accepted while the client is closing.
+type operationKey struct{}
+
+// lifecycle tracks the calls in progress on a client, for Close.
+type lifecycle struct {
+	mu      sync.Mutex
+	closed  bool
+	active  int
+	drained chan struct{}
+
+	// ctx is canceled when Close gives up waiting, aborting the remaining
+	// calls and background work
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{drained: make(chan struct{}), ctx: ctx, cancel: cancel}
+}
+
+// Close stops accepting new calls, then waits for the calls in progress
+// (uploads, job waits, downloads whose body isn't closed yet...) to finish,
+// until ctx is done. Calls still running at that point are canceled, along
+// with the background work of the client, and idle connections are closed.
+//
+// Calls made after Close fail with ErrClientClosed. Close returns ctx's error
+// when it had to cancel calls in progress; calling it again is harmless.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	if err := client.Close(ctx); err != nil {
+//		log.Printf("calls still in progress were canceled: %v", err)
+//	}
+func (c *Client) Close(ctx context.Context) error {
+	l := c.lifecycle
+
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		if l.active == 0 {
+			close(l.drained)
+		}
+	}
+	l.mu.Unlock()
+
+	var err error
+	select {
+	case <-l.drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+		c.logger.Warn("Client closed with calls still in progress", LogKeyError, err)
+	}
+
+	l.cancel()
+	c.httpClient.CloseIdleConnections()
+	return err
+}
+
+// begin registers a call on the client. It returns the context of the call,
+// canceled if Close gives up waiting for it, and a function to call when the
+// call is over (safe to call more than once).
+//
+// Calls made from within another call of the same client are part of it and
+// accepted even while the client is closing.
+func (c *Client) begin(ctx context.Context) (context.Context, func(), error) {
+	l := c.lifecycle
+	if ctx.Value(operationKey{}) == c {
+		return ctx, func() {}, nil
+	}
+
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, nil, ErrClientClosed
+	}
+	l.active++
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, operationKey{}, c))
+	stop := context.AfterFunc(l.ctx, cancel)
+
+	end := sync.OnceFunc(func() {
+		stop()
+		cancel()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.active--
+		if l.closed && l.active == 0 {
+			close(l.drained)
+		}
+	})
+	return ctx, end, nil
+}
+
+// sendTracked registers a request as a call on the client and keeps it
+// registered until its response body is closed.
+func (c *Client) sendTracked(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	ctx, end, err := c.begin(req.Context())
+	if err != nil {
+		return nil, &RequestError{Method: req.Method, Path: req.URL.Path, RequestID: req.Header.Get(RequestIDHeader), Err: err}
+	}
+
+	resp, err := send(req.WithContext(ctx))
+	if err != nil {
+		end()
+		return nil, err
+	}
+	resp.Body = &releasingReadCloser{ReadCloser: resp.Body, release: end}
+	return resp, nil
+}
+
+// detach returns a context for background work of the client, such as
+// endpoint health refreshes. It is derived from none of the calls in
+// progress, so it carries none of their values (request ID, logger fields,
+// trace span), and is only canceled when the client is closed.
+func (c *Client) detach() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.WithValue(c.lifecycle.ctx, operationKey{}, c))
+}
@@ -0,0 +1,188 @@
+package ocfworker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClose_RejectsNewCalls(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	require.NoError(t, client.Close(ctx))
+	require.NoError(t, client.Close(ctx), "Close can be called twice")
+
+	_, err := client.Jobs.Get(ctx, uuid.New().String())
+	assert.ErrorIs(t, err, ErrClientClosed)
+	assert.NotEmpty(t, RequestIDFromError(err))
+
+	_, err = client.Jobs.WaitForCompletion(ctx, uuid.New().String(), nil)
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
+
+func TestClose_WaitsForCallsInProgress(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-proceed
+		RespondJSON(w, http.StatusOK, NewJobResponse().WithID(jobID).Build())
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	getErr := make(chan error, 1)
+	go func() {
+		_, err := client.Jobs.Get(ctx, jobID.String())
+		getErr <- err
+	}()
+	<-entered
+
+	closeErr := make(chan error, 1)
+	go func() { closeErr <- client.Close(ctx) }()
+
+	select {
+	case <-closeErr:
+		t.Fatal("Close returned while a call was in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err := client.Health.Check(ctx)
+	assert.ErrorIs(t, err, ErrClientClosed)
+
+	close(proceed)
+	require.NoError(t, <-getErr)
+	require.NoError(t, <-closeErr)
+}
+
+func TestClose_CancelsAfterDeadline(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	entered := make(chan struct{})
+	server.On("GET", "/api/v1/storage/jobs/job-1/logs", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-r.Context().Done()
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	logsErr := make(chan error, 1)
+	go func() {
+		_, err := client.Storage.GetLogs(ctx, "job-1")
+		logsErr <- err
+	}()
+	<-entered
+
+	closeCtx, closeCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer closeCancel()
+
+	err := client.Close(closeCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, <-logsErr, context.Canceled)
+}
+
+func TestClose_WaitForCompletionKeepsPolling(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	var polls atomic.Int32
+	polled := make(chan struct{}, 10)
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		status := models.StatusProcessing
+		if polls.Add(1) >= 3 {
+			status = models.StatusCompleted
+		}
+		polled <- struct{}{}
+		RespondJSON(w, http.StatusOK, NewJobResponse().WithID(jobID).WithStatus(status).Build())
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	waitErr := make(chan error, 1)
+	go func() {
+		_, err := client.Jobs.WaitForCompletion(ctx, jobID.String(), &WaitOptions{
+			Interval: 20 * time.Millisecond,
+			Timeout:  5 * time.Second,
+		})
+		waitErr <- err
+	}()
+	<-polled
+
+	require.NoError(t, client.Close(ctx))
+	require.NoError(t, <-waitErr)
+	assert.Equal(t, int32(3), polls.Load())
+}
+
+// blockingReader blocks until closed
+type blockingReader struct {
+	done chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	<-r.done
+	return 0, io.EOF
+}
+
+func TestClose_CancelsStreamingUpload(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	entered := make(chan struct{})
+	server.On("POST", "/api/v1/storage/jobs/job-1/sources", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		io.Copy(io.Discard, r.Body)
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	reader := &blockingReader{done: make(chan struct{})}
+	defer close(reader.done)
+
+	uploadErr := make(chan error, 1)
+	go func() {
+		_, err := client.Storage.UploadSourcesStream(ctx, "job-1", []StreamUpload{{Name: "slides.md", Reader: reader}})
+		uploadErr <- err
+	}()
+	<-entered
+
+	closeCtx, closeCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer closeCancel()
+
+	assert.ErrorIs(t, client.Close(closeCtx), context.DeadlineExceeded)
+
+	select {
+	case err := <-uploadErr:
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrClientClosed), "the upload was accepted before Close")
+	case <-time.After(2 * time.Second):
+		t.Fatal("upload still running after Close")
+	}
+}
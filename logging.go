@@ -0,0 +1,325 @@
+package ocfworker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Structured logging keys used consistently by the SDK.
+// Custom Logger implementations can rely on these keys being present
+// whenever the corresponding information is known.
+const (
+	LogKeyJobID     = "job_id"
+	LogKeyCourseID  = "course_id"
+	LogKeyPath      = "path"
+	LogKeyStatus    = "status"
+	LogKeyDuration  = "duration"
+	LogKeyMethod    = "method"
+	LogKeyError     = "error"
+	LogKeyRequestID = "request_id"
+)
+
+// redactedValue replaces sensitive values in log output.
+const redactedValue = "[REDACTED]"
+
+// WithSlogHandler routes all SDK logs to a log/slog handler.
+// Fields are passed as slog attributes, after redaction of tokens and presigned URLs.
+//
+// Example:
+//
+//	handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithSlogHandler(handler))
+func WithSlogHandler(handler slog.Handler) Option {
+	return func(c *Client) {
+		c.logger = NewSlogLogger(slog.New(handler))
+	}
+}
+
+// NewSlogLogger adapts a *slog.Logger to the SDK Logger interface.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+// slogLogger implements Logger on top of a *slog.Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l *slogLogger) Debug(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelDebug, msg, fields...)
+}
+
+func (l *slogLogger) Info(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelInfo, msg, fields...)
+}
+
+func (l *slogLogger) Warn(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelWarn, msg, fields...)
+}
+
+func (l *slogLogger) Error(msg string, fields ...interface{}) {
+	l.logger.Log(context.Background(), slog.LevelError, msg, fields...)
+}
+
+// NewLoggerHandler adapts an SDK Logger to a slog.Handler, so that code using
+// log/slog can write to the same destination as the SDK.
+// Attributes are flattened into key/value fields; groups are joined with dots.
+//
+// Example:
+//
+//	logger := slog.New(ocfworker.NewLoggerHandler(myLogger))
+func NewLoggerHandler(logger Logger) slog.Handler {
+	return &loggerHandler{logger: logger}
+}
+
+// loggerHandler implements slog.Handler on top of a Logger.
+type loggerHandler struct {
+	logger Logger
+	fields []interface{}
+	group  string
+}
+
+func (h *loggerHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *loggerHandler) Handle(_ context.Context, record slog.Record) error {
+	fields := append([]interface{}{}, h.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.group, attr)
+		return true
+	})
+
+	switch {
+	case record.Level >= slog.LevelError:
+		h.logger.Error(record.Message, fields...)
+	case record.Level >= slog.LevelWarn:
+		h.logger.Warn(record.Message, fields...)
+	case record.Level >= slog.LevelInfo:
+		h.logger.Info(record.Message, fields...)
+	default:
+		h.logger.Debug(record.Message, fields...)
+	}
+	return nil
+}
+
+func (h *loggerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append([]interface{}{}, h.fields...)
+	for _, attr := range attrs {
+		fields = appendAttr(fields, h.group, attr)
+	}
+	return &loggerHandler{logger: h.logger, fields: fields, group: h.group}
+}
+
+func (h *loggerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &loggerHandler{logger: h.logger, fields: h.fields, group: joinGroup(h.group, name)}
+}
+
+// appendAttr flattens a slog attribute into key/value fields.
+func appendAttr(fields []interface{}, group string, attr slog.Attr) []interface{} {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		for _, nested := range attr.Value.Group() {
+			fields = appendAttr(fields, joinGroup(group, attr.Key), nested)
+		}
+		return fields
+	}
+	if attr.Key == "" {
+		return fields
+	}
+	return append(fields, joinGroup(group, attr.Key), attr.Value.Any())
+}
+
+func joinGroup(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
+
+// redactingLogger wraps the configured Logger and strips secrets from fields
+// before they reach it. NewClient installs it around every logger.
+type redactingLogger struct {
+	next Logger
+}
+
+func (l *redactingLogger) Debug(msg string, fields ...interface{}) {
+	l.next.Debug(msg, redactFields(fields)...)
+}
+
+func (l *redactingLogger) Info(msg string, fields ...interface{}) {
+	l.next.Info(msg, redactFields(fields)...)
+}
+
+func (l *redactingLogger) Warn(msg string, fields ...interface{}) {
+	l.next.Warn(msg, redactFields(fields)...)
+}
+
+func (l *redactingLogger) Error(msg string, fields ...interface{}) {
+	l.next.Error(msg, redactFields(fields)...)
+}
+
+// sensitiveKeys are field key fragments whose values are never logged.
+var sensitiveKeys = []string{"token", "authorization", "password", "secret", "api_key", "apikey", "credential"}
+
+// sensitiveQueryParams are URL query parameters carrying presigned URL signatures or credentials.
+var sensitiveQueryParams = []string{
+	"x-amz-signature", "x-amz-credential", "x-amz-security-token",
+	"x-goog-signature", "x-goog-credential",
+	"signature", "sig", "token", "access_token", "awsaccesskeyid",
+}
+
+// redactFields returns a copy of fields with sensitive values replaced.
+func redactFields(fields []interface{}) []interface{} {
+	redacted := make([]interface{}, len(fields))
+	copy(redacted, fields)
+
+	for i := 0; i+1 < len(redacted); i += 2 {
+		key, ok := redacted[i].(string)
+		if ok && isSensitiveKey(key) {
+			redacted[i+1] = redactedValue
+			continue
+		}
+		redacted[i+1] = redactValue(redacted[i+1])
+	}
+
+	return redacted
+}
+
+// redactValue strips credentials from values that look like URLs or bearer tokens.
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return redactString(v)
+	case *url.URL:
+		if v == nil {
+			return v
+		}
+		if clean, changed := redactURL(v); changed {
+			return clean
+		}
+		return v
+	case error:
+		if v == nil {
+			return v
+		}
+		return redactString(v.Error())
+	default:
+		return value
+	}
+}
+
+func redactString(s string) string {
+	if strings.HasPrefix(s, "Bearer ") {
+		return "Bearer " + redactedValue
+	}
+	if !strings.Contains(s, "://") {
+		return s
+	}
+
+	// Redact every URL embedded in the string (e.g. in error messages),
+	// leaving the rest of the string byte-for-byte unchanged
+	return urlPattern.ReplaceAllStringFunc(s, func(token string) string {
+		u, err := url.Parse(token)
+		if err != nil {
+			return token
+		}
+		if clean, changed := redactURL(u); changed {
+			return clean
+		}
+		return token
+	})
+}
+
+// urlPattern matches URL-like tokens, stopping at quotes, brackets and
+// separators so that URLs embedded in JSON or error messages are isolated.
+var urlPattern = regexp.MustCompile(`[^\s"'(),<>]+://[^\s"'(),<>]+`)
+
+// redactURL strips userinfo and sensitive query parameters from u. It reports
+// whether anything was stripped; callers should keep the original
+// representation otherwise.
+func redactURL(u *url.URL) (string, bool) {
+	clean := *u
+	changed := false
+	if clean.User != nil {
+		clean.User = url.User(redactedValue)
+		changed = true
+	}
+
+	query := clean.Query()
+	for key := range query {
+		for _, param := range sensitiveQueryParams {
+			if strings.EqualFold(key, param) {
+				query.Set(key, redactedValue)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return u.String(), false
+	}
+	if clean.RawQuery != "" {
+		clean.RawQuery = query.Encode()
+	}
+
+	return clean.String(), true
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatFields renders key/value pairs as "key=value" separated by spaces.
+// A trailing key without value is rendered as "!BADKEY=value", like log/slog does.
+// It is used by the default logger and is exported for custom Logger implementations.
+//
+// Example:
+//
+//	ocfworker.FormatFields("job_id", "123", "duration", 2*time.Second) // "job_id=123 duration=2s"
+func FormatFields(fields ...interface{}) string {
+	var b strings.Builder
+
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if i+1 >= len(fields) {
+			fmt.Fprintf(&b, "!BADKEY=%s", formatFieldValue(fields[i]))
+			break
+		}
+		fmt.Fprintf(&b, "%v=%s", fields[i], formatFieldValue(fields[i+1]))
+	}
+
+	return b.String()
+}
+
+func formatFieldValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case time.Duration:
+		s = v.String()
+	case error:
+		s = v.Error()
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package ocfworker
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// MetricsRecorder collects client-side metrics about the SDK's usage of the
+// OCF Worker API. Implementations must be safe for concurrent use.
+//
+// Endpoints are reported as route templates (e.g. "/jobs/:id") rather than
+// raw paths, so that label cardinality stays bounded.
+//
+// Example:
+//
+//	recorder := ocfworker.NewPrometheusRecorder("")
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithMetrics(recorder))
+//	http.Handle("/metrics", recorder)
+type MetricsRecorder interface {
+	// ObserveRequest records a completed HTTP request. status is 0 when the
+	// request failed before a response was received.
+	ObserveRequest(method, endpoint string, status int, duration time.Duration)
+	// IncRetry records a request that is being retried.
+	IncRetry(endpoint string)
+	// AddUploadBytes records bytes sent in request bodies.
+	AddUploadBytes(endpoint string, n int64)
+	// AddDownloadBytes records bytes read from response bodies.
+	AddDownloadBytes(endpoint string, n int64)
+	// ObserveJobWait records the time spent waiting for a job, labelled by the
+	// status the wait ended with (a job status, "wait_timeout" or "canceled").
+	ObserveJobWait(status string, duration time.Duration)
+}
+
+// WithMetrics sets the recorder receiving client-side metrics.
+// By default metrics are discarded.
+//
+// Example:
+//
+//	recorder := ocfworker.NewPrometheusRecorder("myservice_ocfworker")
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithMetrics(recorder))
+func WithMetrics(recorder MetricsRecorder) Option {
+	return func(c *Client) {
+		c.metrics = recorder
+	}
+}
+
+// noopMetrics is the default MetricsRecorder that discards everything.
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, string, int, time.Duration) {}
+func (noopMetrics) IncRetry(string)                                   {}
+func (noopMetrics) AddUploadBytes(string, int64)                      {}
+func (noopMetrics) AddDownloadBytes(string, int64)                    {}
+func (noopMetrics) ObserveJobWait(string, time.Duration)              {}
+
+// Wait outcomes reported to ObserveJobWait when no terminal job status was reached.
+const (
+	waitStatusTimeout  = "wait_timeout"
+	waitStatusCanceled = "canceled"
+)
+
+// endpointLabel turns a request path into a route template suitable as a metric label.
+// Identifiers following a collection segment become ":id" and file names
+// following "sources" or "results" become ":filename".
+func endpointLabel(path string) string {
+	path = trimAPIPrefix(path)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i := 1; i < len(segments); i++ {
+		switch segments[i-1] {
+		case "jobs", "courses", "workspaces":
+			if segments[i] != "cleanup" {
+				segments[i] = ":id"
+			}
+		case "sources", "results":
+			segments = append(segments[:i], ":filename")
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// countingReadCloser reports the number of bytes read through it.
+type countingReadCloser struct {
+	io.ReadCloser
+	count func(n int64)
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.count(int64(n))
+	}
+	return n, err
+}
+
+// trimAPIPrefix removes the /api/{version} prefix of a request path.
+func trimAPIPrefix(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/v")
+	if !ok {
+		return path
+	}
+	end := strings.IndexByte(rest, '/')
+	if end < 0 {
+		end = len(rest)
+	}
+	if end == 0 || strings.Trim(rest[:end], "0123456789") != "" {
+		return path
+	}
+	return rest[end:]
+}
@@ -0,0 +1,122 @@
+package ocfworker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/v1/health", "/health"},
+		{"/api/v1/jobs", "/jobs"},
+		{"/api/v1/jobs/123e4567-e89b-12d3-a456-426614174000", "/jobs/:id"},
+		{"/api/v1/storage/jobs/abc/sources", "/storage/jobs/:id/sources"},
+		{"/api/v1/storage/jobs/abc/sources/images/logo.png", "/storage/jobs/:id/sources/:filename"},
+		{"/api/v1/storage/courses/abc/results/index.html", "/storage/courses/:id/results/:filename"},
+		{"/api/v1/storage/courses/abc/archive", "/storage/courses/:id/archive"},
+		{"/api/v1/worker/workspaces/cleanup", "/worker/workspaces/cleanup"},
+		{"/api/v1/worker/workspaces/abc", "/worker/workspaces/:id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, endpointLabel(tt.path))
+		})
+	}
+}
+
+func TestPrometheusRecorder_ClientMetrics(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		job := NewJobResponse().WithID(jobID).WithStatus(models.StatusCompleted).Build()
+		RespondJSON(w, http.StatusOK, job)
+	})
+	server.On("POST", "/api/v1/storage/jobs/"+jobID.String()+"/sources", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusCreated, &models.FileUploadResponse{Count: 1})
+	})
+
+	recorder := NewPrometheusRecorder("")
+	client := server.TestClient(WithMetrics(recorder))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	_, err := client.Jobs.WaitForCompletion(ctx, jobID.String(), &WaitOptions{
+		Interval: 10 * time.Millisecond,
+		Timeout:  time.Second,
+	})
+	require.NoError(t, err)
+
+	_, err = client.Storage.UploadSources(ctx, jobID.String(), []FileUpload{MockFileUpload("slides.md", "# Title")})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	recorder.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "# TYPE ocfworker_requests_total counter")
+	assert.Contains(t, body, `ocfworker_requests_total{method="GET",endpoint="/jobs/:id",status="200"} 1`)
+	assert.Contains(t, body, `ocfworker_request_duration_seconds_count{method="POST",endpoint="/storage/jobs/:id/sources",status="201"} 1`)
+	assert.Contains(t, body, `ocfworker_job_wait_duration_seconds_count{status="completed"} 1`)
+	assert.Contains(t, body, `ocfworker_upload_bytes_total{endpoint="/storage/jobs/:id/sources"}`)
+	assert.Contains(t, body, `ocfworker_download_bytes_total{endpoint="/jobs/:id"}`)
+}
+
+func TestPrometheusRecorder_TransportErrors(t *testing.T) {
+	recorder := NewPrometheusRecorder("custom")
+	client := NewClient("http://127.0.0.1:1", WithTimeout(time.Second), WithMetrics(recorder))
+
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	_, err := client.Health.Check(ctx)
+	require.Error(t, err)
+
+	var b strings.Builder
+	require.NoError(t, recorder.WriteText(&b))
+	assert.Contains(t, b.String(), `custom_requests_total{method="GET",endpoint="/health",status="error"} 1`)
+}
+
+func TestPrometheusRecorder_LabelEscaping(t *testing.T) {
+	recorder := NewPrometheusRecorder("")
+	recorder.ObserveJobWait("échoué", time.Second)
+	recorder.IncRetry("a\\b \"c\"\nd")
+
+	var b strings.Builder
+	require.NoError(t, recorder.WriteText(&b))
+	body := b.String()
+
+	assert.Contains(t, body, `ocfworker_job_wait_duration_seconds_count{status="échoué"} 1`)
+	assert.Contains(t, body, `endpoint="a\\b \"c\"\nd"`)
+	assert.NotContains(t, body, `\u00e9`)
+}
+
+func TestPrometheusRecorder_Histogram(t *testing.T) {
+	recorder := NewPrometheusRecorder("")
+	recorder.ObserveJobWait("failed", 3*time.Second)
+	recorder.ObserveJobWait("failed", 45*time.Second)
+
+	var b strings.Builder
+	require.NoError(t, recorder.WriteText(&b))
+	body := b.String()
+
+	assert.Contains(t, body, `ocfworker_job_wait_duration_seconds_bucket{status="failed",le="1"} 0`)
+	assert.Contains(t, body, `ocfworker_job_wait_duration_seconds_bucket{status="failed",le="5"} 1`)
+	assert.Contains(t, body, `ocfworker_job_wait_duration_seconds_bucket{status="failed",le="60"} 2`)
+	assert.Contains(t, body, `ocfworker_job_wait_duration_seconds_bucket{status="failed",le="+Inf"} 2`)
+	assert.Contains(t, body, `ocfworker_job_wait_duration_seconds_sum{status="failed"} 48`)
+}
@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	githttp "github.com/go-git/go-git/v6/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v6/plumbing/transport/ssh"
+)
+
+// Credentials authentifie l'accès à un dépôt privé : jeton (ou mot de passe)
+// en HTTPS, clé privée en SSH. Sans clé, SSH utilise l'agent ssh-agent.
+//
+// Les identifiants n'apparaissent jamais dans les logs : String les masque.
+type Credentials struct {
+	// Username accompagne le jeton en HTTPS (défaut selon le fournisseur)
+	Username string
+	// Token est un jeton d'accès personnel ou un mot de passe
+	Token string
+	// SSHKeyFile est le chemin d'une clé privée SSH
+	SSHKeyFile string
+	// SSHKeyPassphrase déchiffre la clé SSH si elle est protégée
+	SSHKeyPassphrase string
+}
+
+// String décrit les identifiants sans les révéler
+func (c *Credentials) String() string {
+	switch {
+	case c == nil:
+		return "anonyme"
+	case c.SSHKeyFile != "":
+		return "clé SSH " + c.SSHKeyFile
+	case c.Token != "":
+		return "jeton (" + c.Username + ")"
+	}
+	return "anonyme"
+}
+
+// GoString masque les identifiants avec %#v
+func (c *Credentials) GoString() string {
+	return c.String()
+}
+
+// tokenEnvVars sont les variables d'environnement lues pour chaque fournisseur
+var tokenEnvVars = map[string][]string{
+	"github": {"GITHUB_TOKEN", "GH_TOKEN"},
+	"gitlab": {"GITLAB_TOKEN"},
+	"gitea":  {"GITEA_TOKEN", "FORGEJO_TOKEN"},
+}
+
+// tokenUsernames sont les noms d'utilisateur attendus avec un jeton en HTTPS
+var tokenUsernames = map[string]string{
+	"github": "x-access-token",
+	"gitlab": "oauth2",
+}
+
+// credentialsFor détermine les identifiants d'une source : ceux de la
+// configuration, puis ceux de l'URL, puis les variables d'environnement du
+// fournisseur. Retourne nil pour un accès anonyme.
+func (c *Config) credentialsFor(provider string, source *Source) *Credentials {
+	creds := source.Auth
+	switch {
+	case c.GitToken != "" || c.SSHKeyFile != "":
+		creds = &Credentials{
+			Username:         c.GitUsername,
+			Token:            c.GitToken,
+			SSHKeyFile:       c.SSHKeyFile,
+			SSHKeyPassphrase: c.SSHKeyPassphrase,
+		}
+	case creds == nil:
+		for _, name := range tokenEnvVars[provider] {
+			if token := os.Getenv(name); token != "" {
+				creds = &Credentials{Token: token}
+				break
+			}
+		}
+	}
+
+	if creds != nil && creds.Token != "" && creds.Username == "" {
+		creds.Username = tokenUsernames[provider]
+		if creds.Username == "" {
+			creds.Username = "git"
+		}
+	}
+	return creds
+}
+
+// gitAuth convertit les identifiants pour go-git ; nil laisse go-git utiliser
+// l'accès anonyme en HTTPS ou ssh-agent en SSH
+func (c *Credentials) gitAuth(cloneURL string) (transport.AuthMethod, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "ssh" {
+		if c.SSHKeyFile == "" {
+			return nil, nil
+		}
+		user := u.User.Username()
+		if user == "" {
+			user = "git"
+		}
+		auth, err := gitssh.NewPublicKeysFromFile(user, c.SSHKeyFile, c.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("lecture de la clé SSH %s: %w", c.SSHKeyFile, err)
+		}
+		return auth, nil
+	}
+
+	if c.Token == "" {
+		return nil, nil
+	}
+	return &githttp.BasicAuth{Username: c.Username, Password: c.Token}, nil
+}
+
+// isAuthError indique si l'accès au dépôt a été refusé faute d'identifiants
+func isAuthError(err error) bool {
+	return errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed)
+}
+
+// gitCredentialFill interroge les credential helpers de git (git credential
+// fill) pour une URL HTTPS. Aucune invite n'est affichée : sans identifiants
+// enregistrés, retourne nil.
+func gitCredentialFill(ctx context.Context, cloneURL string) *Credentials {
+	u, err := url.Parse(cloneURL)
+	if err != nil || !isHTTPURL(u) {
+		return nil
+	}
+
+	input := fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n\n", u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/"))
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	creds := &Credentials{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "username":
+			creds.Username = value
+		case "password":
+			creds.Token = value
+		}
+	}
+	if creds.Token == "" {
+		return nil
+	}
+	return creds
+}
+
+// redactURL retire les identifiants éventuels d'une URL avant de l'afficher
+// ou de la transmettre au worker, y compris d'une URL invalide ou sans
+// protocole. Seul l'utilisateur d'une URL SSH courte (git@host:repo) est gardé.
+func redactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.User != nil {
+		u.User = nil
+		return u.String()
+	}
+
+	prefix, rest, hasScheme := strings.Cut(rawURL, "://")
+	if hasScheme {
+		prefix += "://"
+	} else {
+		prefix, rest = "", rawURL
+	}
+	authority, _, _ := strings.Cut(rest, "/")
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return rawURL
+	}
+	user, host := authority[:at], authority[at+1:]
+	if !hasScheme && !strings.Contains(user, ":") && strings.Contains(host, ":") {
+		return rawURL
+	}
+	return prefix + rest[at+1:]
+}
@@ -0,0 +1,319 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCacheMaxSize est la taille maximale du cache des sources par défaut
+const DefaultCacheMaxSize int64 = 1 << 30 // 1 Go
+
+const (
+	// cacheFilesDir contient les fichiers d'une entrée, tels qu'uploadés
+	cacheFilesDir = "files"
+	// cacheEntryFile décrit une entrée ; sa date de modification sert à l'éviction LRU
+	cacheEntryFile = "entry.json"
+)
+
+// Cache conserve les sources téléchargées entre deux générations, par
+// fournisseur, dépôt et commit : un commit déjà téléchargé n'est plus
+// récupéré. Les entrées les moins récemment utilisées sont supprimées au-delà
+// de MaxSize.
+type Cache struct {
+	// Dir est la racine du cache
+	Dir string
+	// MaxSize borne la taille totale du cache en octets (0 ou moins : sans limite)
+	MaxSize int64
+}
+
+// CacheEntry décrit les sources d'un commit présentes dans le cache
+type CacheEntry struct {
+	Provider string    `json:"provider"`
+	Repo     string    `json:"repo"`
+	Commit   string    `json:"commit"`
+	Path     string    `json:"path,omitempty"`
+	URL      string    `json:"url"`
+	Files    int       `json:"files"`
+	Size     int64     `json:"size"`
+	Created  time.Time `json:"created"`
+
+	// LastUsed est la date de dernière utilisation
+	LastUsed time.Time `json:"-"`
+	// Dir est le répertoire de l'entrée
+	Dir string `json:"-"`
+}
+
+// FilesDir retourne le répertoire des fichiers de l'entrée
+func (e *CacheEntry) FilesDir() string {
+	return filepath.Join(e.Dir, cacheFilesDir)
+}
+
+// DefaultCacheDir retourne le répertoire du cache des sources, sous le
+// répertoire de cache de l'utilisateur (ex: ~/.cache/ocf-worker-cli/sources)
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("répertoire de cache introuvable: %w", err)
+	}
+	return filepath.Join(dir, "ocf-worker-cli", "sources"), nil
+}
+
+// NewCache crée un cache dans dir (DefaultCacheDir si vide)
+func NewCache(dir string, maxSize int64) (*Cache, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	return &Cache{Dir: dir, MaxSize: maxSize}, nil
+}
+
+// entryDir retourne le répertoire d'une source résolue. Un sous-dossier ne
+// contient qu'une partie du commit : il a sa propre entrée.
+func (c *Cache) entryDir(provider string, source *Source) string {
+	name := source.Commit
+	if source.Path != "" {
+		sum := sha256.Sum256([]byte(source.Path))
+		name += "-" + hex.EncodeToString(sum[:4])
+	}
+	return filepath.Join(c.Dir, provider, filepath.FromSlash(source.Repo), name)
+}
+
+// Get retourne l'entrée d'une source résolue si elle est en cache, et la
+// marque comme utilisée
+func (c *Cache) Get(provider string, source *Source) (*CacheEntry, bool) {
+	if source.Commit == "" {
+		return nil, false
+	}
+
+	entry, err := readCacheEntry(c.entryDir(provider, source))
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(filepath.Join(entry.Dir, cacheEntryFile), now, now)
+	entry.LastUsed = now
+	return entry, true
+}
+
+// Put ajoute au cache les sources d'une source résolue : fetch les écrit dans
+// le répertoire fourni. L'entrée n'est visible qu'une fois complète ; si une
+// autre génération l'a ajoutée entre-temps, la sienne est conservée.
+func (c *Cache) Put(provider string, source *Source, fetch func(dir string) ([]string, error)) (*CacheEntry, error) {
+	if source.Commit == "" {
+		return nil, fmt.Errorf("commit non résolu pour %s", source)
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, err
+	}
+	tempDir, err := os.MkdirTemp(c.Dir, ".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Le répertoire des fichiers existe même si fetch n'en écrit aucun
+	filesDir := filepath.Join(tempDir, cacheFilesDir)
+	if err := os.Mkdir(filesDir, 0755); err != nil {
+		return nil, err
+	}
+	files, err := fetch(filesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &CacheEntry{
+		Provider: provider,
+		Repo:     source.Repo,
+		Commit:   source.Commit,
+		Path:     source.Path,
+		URL:      source.URL,
+		Files:    len(files),
+		Created:  time.Now(),
+	}
+	if entry.Size, err = dirSize(tempDir); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, cacheEntryFile), data, 0644); err != nil {
+		return nil, err
+	}
+
+	dir := c.entryDir(provider, source)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tempDir, dir); err != nil {
+		if existing, ok := c.Get(provider, source); ok {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("ajout au cache: %w", err)
+	}
+
+	entry.Dir = dir
+	entry.LastUsed = entry.Created
+	return entry, nil
+}
+
+// List retourne les entrées du cache, des plus récemment utilisées aux plus
+// anciennes
+func (c *Cache) List() ([]*CacheEntry, error) {
+	var entries []*CacheEntry
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".tmp-") {
+			return filepath.SkipDir
+		}
+
+		// Seul un entry.json à l'emplacement qu'il décrit est une entrée
+		if entry, err := readCacheEntry(path); err == nil && c.isEntryDir(entry) {
+			entries = append(entries, entry)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Prune supprime les entrées les moins récemment utilisées jusqu'à ce que
+// le cache tienne dans maxSize octets, et retourne les entrées supprimées
+func (c *Cache) Prune(maxSize int64) ([]*CacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	var removed []*CacheEntry
+	for i := len(entries) - 1; i >= 0 && total > maxSize; i-- {
+		if err := c.remove(entries[i]); err != nil {
+			return removed, err
+		}
+		total -= entries[i].Size
+		removed = append(removed, entries[i])
+	}
+	return removed, nil
+}
+
+// Clear supprime toutes les entrées du cache et les ajouts interrompus. Les
+// autres fichiers de Dir sont conservés : un répertoire de cache erroné (ex:
+// le répertoire personnel) ne perd que ce qui ressemble à une entrée.
+func (c *Cache) Clear() error {
+	entries, err := c.List()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := c.remove(entry); err != nil {
+			return err
+		}
+	}
+
+	// Répertoires temporaires de Put (os.MkdirTemp ajoute des chiffres)
+	temps, err := filepath.Glob(filepath.Join(c.Dir, ".tmp-[0-9]*"))
+	if err != nil {
+		return err
+	}
+	for _, temp := range temps {
+		if err := os.RemoveAll(temp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEntryDir vérifie qu'une entrée lue est à l'emplacement que lui donnerait Put
+func (c *Cache) isEntryDir(entry *CacheEntry) bool {
+	if entry.Provider == "" || entry.Repo == "" || entry.Commit == "" {
+		return false
+	}
+	source := &Source{Repo: entry.Repo, Commit: entry.Commit, Path: entry.Path}
+	return filepath.Clean(entry.Dir) == filepath.Clean(c.entryDir(entry.Provider, source))
+}
+
+// remove supprime une entrée et les répertoires parents devenus vides
+func (c *Cache) remove(entry *CacheEntry) error {
+	if err := os.RemoveAll(entry.Dir); err != nil {
+		return err
+	}
+	for dir := filepath.Dir(entry.Dir); dir != c.Dir && strings.HasPrefix(dir, c.Dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// readCacheEntry lit la description d'une entrée du cache
+func readCacheEntry(dir string) (*CacheEntry, error) {
+	path := filepath.Join(dir, cacheEntryFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("entrée de cache invalide %s: %w", dir, err)
+	}
+	entry.Dir = dir
+	entry.LastUsed = info.ModTime()
+	return &entry, nil
+}
+
+// dirSize calcule la taille des fichiers d'un répertoire
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachePutGet(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	source := &Source{Repo: "owner/repo", Commit: "abc123", URL: "https://github.com/owner/repo"}
+
+	_, ok := cache.Get("github", source)
+	assert.False(t, ok)
+
+	entry, err := cache.Put("github", source, func(dir string) ([]string, error) {
+		file := filepath.Join(dir, "slides.md")
+		return []string{file}, os.WriteFile(file, []byte("# Hello"), 0644)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, entry.Files)
+	assert.EqualValues(t, len("# Hello"), entry.Size)
+
+	cached, ok := cache.Get("github", source)
+	require.True(t, ok)
+	assert.Equal(t, entry.Dir, cached.Dir)
+	assert.FileExists(t, filepath.Join(cached.FilesDir(), "slides.md"))
+
+	// Un sous-dossier a sa propre entrée
+	_, ok = cache.Get("github", &Source{Repo: "owner/repo", Commit: "abc123", Path: "slides"})
+	assert.False(t, ok)
+}
+
+func TestCachePut_NoFiles(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	source := &Source{Repo: "owner/repo", Commit: "abc123", Path: "empty"}
+
+	entry, err := cache.Put("github", source, func(dir string) ([]string, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, entry.Files)
+
+	// L'entrée reste utilisable : son répertoire de fichiers existe, vide
+	cached, ok := cache.Get("github", source)
+	require.True(t, ok)
+	files, err := collectLocalFiles(cached.FilesDir())
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestCachePut_FetchError(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	source := &Source{Repo: "owner/repo", Commit: "abc123"}
+
+	_, err := cache.Put("github", source, func(dir string) ([]string, error) {
+		return nil, os.ErrPermission
+	})
+	require.ErrorIs(t, err, os.ErrPermission)
+
+	_, ok := cache.Get("github", source)
+	assert.False(t, ok)
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCacheClear(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	for _, commit := range []string{"abc123", "def456"} {
+		_, err := cache.Put("github", &Source{Repo: "owner/repo", Commit: commit}, func(dir string) ([]string, error) {
+			file := filepath.Join(dir, "slides.md")
+			return []string{file}, os.WriteFile(file, []byte("# Hello"), 0644)
+		})
+		require.NoError(t, err)
+	}
+	interrupted, err := os.MkdirTemp(cache.Dir, ".tmp-")
+	require.NoError(t, err)
+
+	// Des fichiers étrangers au cache, comme dans un --cache-dir erroné
+	writeSourcesIn(t, cache.Dir, map[string]string{
+		"notes.txt":               "notes",
+		"project/entry.json":      `{"provider": "github", "repo": "owner/repo", "commit": "abc123"}`,
+		"project/files/README.md": "# Project",
+		".tmp-config":             "keep",
+	})
+
+	require.NoError(t, cache.Clear())
+
+	entries, err := cache.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.NoDirExists(t, interrupted)
+	assert.NoDirExists(t, filepath.Join(cache.Dir, "github"))
+	assert.FileExists(t, filepath.Join(cache.Dir, "notes.txt"))
+	assert.FileExists(t, filepath.Join(cache.Dir, "project", "entry.json"))
+	assert.FileExists(t, filepath.Join(cache.Dir, "project", "files", "README.md"))
+	assert.FileExists(t, filepath.Join(cache.Dir, ".tmp-config"))
+}
@@ -3,13 +3,37 @@ package generator
 import (
 	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
 // Config contient la configuration pour la génération
 type Config struct {
-	GitHubURL    string
+	// Source : un dépôt distant ou un répertoire local (l'un ou l'autre)
+	RepoURL   string
+	SourceDir string
+	// Provider force le fournisseur du dépôt (github, gitlab, gitea, git) ;
+	// vide pour le déduire de l'URL
+	Provider string
+	// Ref sélectionne une branche, un tag ou un commit, prioritaire sur l'URL
+	Ref string
+
+	// Accès aux dépôts privés : jeton HTTPS ou clé SSH. À défaut, les
+	// variables d'environnement du fournisseur (GITHUB_TOKEN, GITLAB_TOKEN...)
+	// puis les credential helpers de git sont utilisés.
+	GitUsername      string
+	GitToken         string
+	SSHKeyFile       string
+	SSHKeyPassphrase string
+
+	// Cache des sources par commit : CacheDir vide pour DefaultCacheDir,
+	// CacheMaxSize à 0 pour DefaultCacheMaxSize, négatif pour ne pas le borner
+	NoCache      bool
+	CacheDir     string
+	CacheMaxSize int64
+
 	APIBaseURL   string
 	AuthToken    string
 	OutputDir    string
@@ -19,17 +43,106 @@ type Config struct {
 	WaitInterval time.Duration
 	Verbose      bool
 	NpmPackages  []string
+	// Entry est le fichier principal de la présentation, relatif au
+	// sous-dossier ; vide pour le détecter
+	Entry string
+	// Decks liste les fichiers principaux de plusieurs présentations à
+	// générer, chacune dans un sous-dossier de OutputDir ; AllDecks les
+	// détecte dans les sources. Concurrency borne les générations simultanées.
+	Decks       []string
+	AllDecks    bool
+	Concurrency int
+	// Force relance la génération même si un job précédent a les mêmes sources
+	Force bool
+
+	// Include et Exclude filtrent les fichiers uploadés (motifs relatifs aux
+	// sources, ** pour plusieurs dossiers) ; Formats choisit les résultats à
+	// télécharger (html, zip, tar ; DefaultFormats si vide) ; Metadata est
+	// ajouté aux métadonnées des jobs
+	Include  []string
+	Exclude  []string
+	Formats  []string
+	Metadata map[string]interface{}
+
+	// Certificat client (mTLS) et autorités de certification du worker
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+}
+
+// SetSource renseigne la source depuis un argument de la ligne de commande :
+// une URL de dépôt, un chemin local ou une URL file://
+func (c *Config) SetSource(source string) {
+	if dir, ok := localSourceDir(source); ok {
+		c.SourceDir = dir
+		return
+	}
+	c.RepoURL = source
+}
+
+// IsLocal indique si la source est un répertoire local
+func (c *Config) IsLocal() bool {
+	return c.SourceDir != ""
+}
+
+// Source décrit la source pour les logs
+func (c *Config) Source() string {
+	if c.IsLocal() {
+		return c.SourceDir
+	}
+	return redactURL(c.RepoURL)
+}
+
+// IsMultiDeck indique si plusieurs présentations sont à générer
+func (c *Config) IsMultiDeck() bool {
+	return c.AllDecks || len(c.Decks) > 0
+}
+
+// localSourceDir reconnaît les chemins locaux et les URLs file://. Un chemin
+// qui commence par un nom d'hôte (ex: github.com/user/repo) et n'existe pas
+// localement est une URL sans protocole, refusée par parseRepoURL.
+func localSourceDir(source string) (string, bool) {
+	if strings.HasPrefix(source, "file://") {
+		u, err := url.Parse(source)
+		if err != nil || u.Path == "" {
+			return "", false
+		}
+		return filepath.FromSlash(u.Path), true
+	}
+	if strings.Contains(source, "://") || scpLikeURL.MatchString(source) {
+		return "", false
+	}
+	if hostLikePath.MatchString(redactURL(source)) {
+		if _, err := os.Stat(source); err != nil {
+			return "", false
+		}
+	}
+	return source, true
 }
 
 // Validate valide la configuration
 func (c *Config) Validate() error {
-	if c.GitHubURL == "" {
-		return fmt.Errorf("URL GitHub requise")
-	}
+	switch {
+	case c.RepoURL != "" && c.SourceDir != "":
+		return fmt.Errorf("une seule source possible: URL de dépôt ou répertoire local")
 
-	// Valider l'URL GitHub
-	if !strings.HasPrefix(c.GitHubURL, "https://github.com/") {
-		return fmt.Errorf("URL GitHub invalide: doit commencer par https://github.com/")
+	case c.SourceDir != "":
+		info, err := os.Stat(c.SourceDir)
+		if err != nil {
+			return fmt.Errorf("répertoire source invalide: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("répertoire source invalide: %s n'est pas un répertoire", c.SourceDir)
+		}
+
+	case c.RepoURL == "":
+		return fmt.Errorf("URL de dépôt ou répertoire local requis")
+
+	// Valider l'URL du dépôt
+	default:
+		if _, _, err := SelectProvider(DefaultProviders(), c.RepoURL, c.Provider); err != nil {
+			return err
+		}
 	}
 
 	// Valider l'URL de l'API
@@ -37,15 +150,44 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("URL API invalide: %w", err)
 	}
 
-	// Valeurs par défaut
-	if c.OutputDir == "" {
-		c.OutputDir = "./output"
+	if c.Entry != "" && c.IsMultiDeck() {
+		return fmt.Errorf("--entry ne s'utilise pas avec plusieurs présentations")
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("le certificat client et sa clé doivent être fournis ensemble")
 	}
 
+	for _, format := range c.Formats {
+		if format != FormatHTML && format != FormatZIP && format != FormatTAR {
+			return fmt.Errorf("format %q invalide (html, zip ou tar)", format)
+		}
+	}
+
+	// Valeurs par défaut nécessaires à la création du générateur ; les
+	// autres sont appliquées après lecture du manifeste (voir applyDefaults)
 	if c.Timeout == 0 {
 		c.Timeout = 60 * time.Second
 	}
 
+	if c.CacheMaxSize == 0 {
+		c.CacheMaxSize = DefaultCacheMaxSize
+	}
+
+	return nil
+}
+
+// applyDefaults renseigne les valeurs par défaut des réglages que ni la
+// configuration ni le manifeste ne précisent
+func (c *Config) applyDefaults() {
+	if c.OutputDir == "" {
+		c.OutputDir = "./output"
+	}
+
+	if c.Concurrency <= 0 {
+		c.Concurrency = DefaultConcurrency
+	}
+
 	if c.WaitTimeout == 0 {
 		c.WaitTimeout = 15 * time.Minute
 	}
@@ -54,5 +196,7 @@ func (c *Config) Validate() error {
 		c.WaitInterval = 5 * time.Second
 	}
 
-	return nil
+	if len(c.Formats) == 0 {
+		c.Formats = DefaultFormats
+	}
 }
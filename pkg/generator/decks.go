@@ -0,0 +1,284 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	ocfworker "ocf-worker-sdk"
+)
+
+// DefaultConcurrency est le nombre de decks générés en parallèle par défaut
+const DefaultConcurrency = 2
+
+// detectDecks retourne les fichiers principaux de toutes les présentations
+// des sources : les fichiers aux noms usuels (slides.md...) et les fichiers
+// Markdown dont le frontmatter est celui d'une présentation, à toute profondeur
+func detectDecks(baseDir string, names []string) []string {
+	var decks []string
+	for _, name := range markdownFiles(names, false) {
+		if slices.Contains(conventionalEntries, path.Base(name)) {
+			decks = append(decks, name)
+			continue
+		}
+		frontmatter, err := readFrontmatter(filepath.Join(baseDir, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		for _, key := range slidevHeadmatterKeys {
+			if _, ok := frontmatter[key]; ok {
+				decks = append(decks, name)
+				break
+			}
+		}
+	}
+	return decks
+}
+
+// deckNames attribue à chaque deck un nom unique, utilisé comme sous-dossier
+// de sortie : chapter1/slides.md devient chapter1, intro.md devient intro
+func deckNames(entries []string) []string {
+	names := make([]string, len(entries))
+	used := map[string]bool{}
+	for i, entry := range entries {
+		dir, base := path.Split(entry)
+		name := strings.TrimSuffix(base, path.Ext(base))
+		if dir != "" {
+			if slices.Contains(conventionalEntries, base) {
+				name = strings.TrimSuffix(dir, "/")
+			} else {
+				name = dir + name
+			}
+		}
+		name = strings.ReplaceAll(name, "/", "-")
+
+		// Un nom déjà pris reçoit le premier suffixe -2, -3... libre
+		unique := name
+		for n := 2; used[unique]; n++ {
+			unique = fmt.Sprintf("%s-%d", name, n)
+		}
+		used[unique] = true
+		names[i] = unique
+	}
+	return names
+}
+
+// generateDecks génère une présentation par deck, au plus Concurrency à la
+// fois, chacune dans son sous-dossier de OutputDir, puis une page d'index qui
+// les regroupe. Les sources sont récupérées une seule fois, mais chaque job
+// a ses propres sources côté worker : chaque deck n'uploade que les fichiers
+// de son dossier et les fichiers partagés (voir deckUploads). En cas d'échec
+// de certains decks, les autres sont tout de même générés : le résultat est
+// retourné avec une erreur.
+func (g *Generator) generateDecks(ctx context.Context, sources *fetchedSources, uploads []ocfworker.StreamUpload, names []string) (*Result, error) {
+	entries, err := g.deckEntries(sources.dir, names)
+	if err != nil {
+		return nil, err
+	}
+
+	deckDirs := deckNames(entries)
+	deckFiles := deckUploads(uploads, entries)
+	g.logger.Printf("📚 %d présentations: %s", len(entries), strings.Join(entries, ", "))
+
+	decks := make([]*DeckResult, len(entries))
+	sem := make(chan struct{}, g.config.Concurrency)
+	var wg sync.WaitGroup
+
+	for i, entry := range entries {
+		uploads := deckFiles[i]
+		deck := &DeckResult{Name: deckDirs[i], Entry: entry, Title: deckTitle(sources.dir, entry)}
+		decks[i] = deck
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				deck.Err = ctx.Err()
+				return
+			}
+
+			// Un générateur par deck, pour préfixer ses logs
+			deckGen := *g
+			deckGen.logger = log.New(g.logger.Writer(), "["+deck.Name+"] ", g.logger.Flags()|log.Lmsgprefix)
+
+			deck.Result, deck.Err = deckGen.generateDeck(ctx, sources, uploads, entry, filepath.Join(g.config.OutputDir, deck.Name))
+			if deck.Err != nil {
+				deckGen.logger.Printf("❌ %v", deck.Err)
+			} else {
+				deckGen.logger.Printf("✅ Présentation générée")
+			}
+		}()
+	}
+	wg.Wait()
+
+	indexPath, err := writeDecksIndex(g.config.OutputDir, decks)
+	if err != nil {
+		return nil, fmt.Errorf("création de l'index échouée: %w", err)
+	}
+	g.logger.Printf("🗂️ Index des présentations: %s", indexPath)
+
+	result := &Result{
+		OutputDir: g.config.OutputDir,
+		IndexPath: indexPath,
+		Decks:     decks,
+	}
+
+	var errs []error
+	for _, deck := range decks {
+		if deck.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", deck.Entry, deck.Err))
+		}
+	}
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%d présentation(s) sur %d en échec: %w", len(errs), len(decks), errors.Join(errs...))
+	}
+
+	g.logger.Printf("🎉 Génération terminée avec succès!")
+	return result, nil
+}
+
+// deckEntries retourne les fichiers principaux à générer : ceux de la
+// configuration, ou tous ceux détectés dans les sources
+func (g *Generator) deckEntries(baseDir string, names []string) ([]string, error) {
+	if !g.config.AllDecks {
+		entries := make([]string, 0, len(g.config.Decks))
+		for _, deck := range g.config.Decks {
+			entry, err := detectEntry(baseDir, names, deck)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		return entries, nil
+	}
+
+	entries := detectDecks(baseDir, names)
+	if len(entries) == 0 {
+		return nil, entryError("aucune présentation trouvée", baseDir, names)
+	}
+	sort.Strings(entries)
+	return entries, nil
+}
+
+// deckUploads répartit les fichiers à uploader entre les decks. Un fichier
+// appartient au deck dont le dossier est le plus proche parmi ses parents ;
+// les fichiers hors de tout dossier de deck (thème, composants, images
+// communes...) sont partagés et uploadés avec chaque deck. Les decks à la
+// racine des sources n'ont pas de dossier propre et ne reçoivent que les
+// fichiers partagés.
+func deckUploads(uploads []ocfworker.StreamUpload, entries []string) [][]ocfworker.StreamUpload {
+	folders := map[string]bool{}
+	for _, entry := range entries {
+		if dir := path.Dir(entry); dir != "." {
+			folders[dir] = true
+		}
+	}
+
+	owner := func(name string) string {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if folders[dir] {
+				return dir
+			}
+		}
+		return ""
+	}
+
+	result := make([][]ocfworker.StreamUpload, len(entries))
+	for _, upload := range uploads {
+		folder := owner(upload.Name)
+		for i, entry := range entries {
+			if dir := path.Dir(entry); folder == "" || folder == dir {
+				result[i] = append(result[i], upload)
+			}
+		}
+	}
+	return result
+}
+
+// deckTitle retourne le titre d'un deck (title de son frontmatter), ou son
+// fichier principal à défaut
+func deckTitle(baseDir, entry string) string {
+	frontmatter, err := readFrontmatter(filepath.Join(baseDir, filepath.FromSlash(entry)))
+	if err == nil {
+		if title, ok := frontmatter["title"].(string); ok && title != "" {
+			return title
+		}
+	}
+	return entry
+}
+
+// decksIndexTemplate est la page qui regroupe les présentations générées
+var decksIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<title>Présentations</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 3rem auto; padding: 0 1rem; }
+li { margin: .5rem 0; }
+.error { color: #b00020; }
+small { color: #666; }
+</style>
+</head>
+<body>
+<h1>Présentations</h1>
+<ul>
+{{- range .}}
+<li>{{if .Href}}<a href="{{.Href}}">{{.Title}}</a>{{else}}<span class="error">{{.Title}} — échec: {{.Error}}</span>{{end}} <small>{{.Entry}}</small></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+// writeDecksIndex écrit dans outputDir la page d'index des decks
+func writeDecksIndex(outputDir string, decks []*DeckResult) (string, error) {
+	type link struct {
+		Title, Entry, Href, Error string
+	}
+
+	links := make([]link, 0, len(decks))
+	for _, deck := range decks {
+		l := link{Title: deck.Title, Entry: deck.Entry}
+		switch {
+		case deck.Err != nil:
+			l.Error = deck.Err.Error()
+		case deck.Result != nil:
+			href, err := filepath.Rel(outputDir, deck.Result.MainPath())
+			if err != nil {
+				return "", err
+			}
+			l.Href = filepath.ToSlash(href)
+		}
+		links = append(links, l)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+
+	indexPath := filepath.Join(outputDir, "index.html")
+	file, err := os.Create(indexPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := decksIndexTemplate.Execute(file, links); err != nil {
+		return "", err
+	}
+	return indexPath, nil
+}
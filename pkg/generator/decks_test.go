@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocfworker "ocf-worker-sdk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeckNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    []string
+	}{
+		{
+			name:    "conventional names use their folder",
+			entries: []string{"chapter1/slides.md", "chapter2/deck.md", "intro.md"},
+			want:    []string{"chapter1", "chapter2", "intro"},
+		},
+		{
+			name:    "nested files",
+			entries: []string{"part1/chapter1/slides.md", "part1/extra.md"},
+			want:    []string{"part1-chapter1", "part1-extra"},
+		},
+		{
+			name:    "collision",
+			entries: []string{"chapter1/slides.md", "chapter1.md"},
+			want:    []string{"chapter1", "chapter1-2"},
+		},
+		{
+			name:    "collision in sorted order",
+			entries: []string{"chapter1.md", "chapter1/slides.md", "chapter1/deck.md"},
+			want:    []string{"chapter1", "chapter1-2", "chapter1-3"},
+		},
+		{
+			name:    "suffix already taken",
+			entries: []string{"a.md", "a-2.md", "a/slides.md"},
+			want:    []string{"a", "a-2", "a-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, deckNames(tt.entries))
+		})
+	}
+}
+
+func TestGenerate_MultiDeckPartialFailure(t *testing.T) {
+	worker := newFakeWorker(t)
+	worker.failEntry = "chapter2/slides.md"
+	sourceDir := writeSources(t, map[string]string{
+		"chapter1/slides.md": "---\ntitle: Chapitre 1\n---\n\n# Un\n",
+		"chapter2/slides.md": "# Deux\n",
+		"intro.md":           slidevHeadmatter,
+		"README.md":          "# Cours\n",
+	})
+	ctx, cancel := ocfworker.TestContext()
+	defer cancel()
+
+	gen := newTestGenerator(t, worker, &Config{SourceDir: sourceDir, AllDecks: true})
+	result, err := gen.Generate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 présentation(s) sur 3 en échec")
+	assert.Contains(t, err.Error(), "chapter2/slides.md")
+
+	// Les autres decks sont générés, et l'index les regroupe
+	require.NotNil(t, result)
+	require.Len(t, result.Decks, 3)
+	assert.Equal(t, 2, worker.completedJobs())
+
+	decks := map[string]*DeckResult{}
+	for _, deck := range result.Decks {
+		decks[deck.Name] = deck
+	}
+	require.Contains(t, decks, "chapter1")
+	require.Contains(t, decks, "chapter2")
+	require.Contains(t, decks, "intro")
+
+	assert.NoError(t, decks["chapter1"].Err)
+	assert.Equal(t, "Chapitre 1", decks["chapter1"].Title)
+	assert.FileExists(t, filepath.Join(gen.config.OutputDir, "chapter1", "presentation", "index.html"))
+	assert.NoError(t, decks["intro"].Err)
+	assert.Nil(t, decks["chapter2"].Result)
+	assert.ErrorContains(t, decks["chapter2"].Err, "build failed")
+
+	index, err := os.ReadFile(result.IndexPath)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(gen.config.OutputDir, "index.html"), result.IndexPath)
+	assert.Contains(t, string(index), `<a href="chapter1/presentation/index.html">Chapitre 1</a>`)
+	assert.Contains(t, string(index), `<a href="intro/presentation/index.html">intro.md</a>`)
+	assert.Contains(t, string(index), `chapter2/slides.md — échec:`)
+}
+
+func TestGenerate_MultiDeckUploadsOwnFiles(t *testing.T) {
+	worker := newFakeWorker(t)
+	sourceDir := writeSources(t, map[string]string{
+		"chapter1/slides.md":          "# Un\n",
+		"chapter1/images/schema.png":  "png",
+		"chapter2/slides.md":          "# Deux\n",
+		"chapter2/nested/slides.md":   "# Deux bis\n",
+		"chapter2/nested/diagram.svg": "svg",
+		"components/Counter.vue":      "<template/>",
+		"intro.md":                    slidevHeadmatter,
+	})
+	ctx, cancel := ocfworker.TestContext()
+	defer cancel()
+
+	gen := newTestGenerator(t, worker, &Config{SourceDir: sourceDir, AllDecks: true})
+	_, err := gen.Generate(ctx)
+	require.NoError(t, err)
+
+	// Chaque deck reçoit ses fichiers et les fichiers partagés, pas ceux des
+	// autres decks
+	shared := []string{"components/Counter.vue", "intro.md"}
+	assert.Equal(t, map[string][]string{
+		"chapter1/slides.md":        append([]string{"chapter1/images/schema.png", "chapter1/slides.md"}, shared...),
+		"chapter2/slides.md":        append([]string{"chapter2/slides.md"}, shared...),
+		"chapter2/nested/slides.md": append([]string{"chapter2/nested/diagram.svg", "chapter2/nested/slides.md"}, shared...),
+		"intro.md":                  shared,
+	}, worker.uploadedSources())
+}
+
+func TestWriteDecksIndex(t *testing.T) {
+	outputDir := t.TempDir()
+	decks := []*DeckResult{
+		{
+			Name:   "chapter1",
+			Entry:  "chapter1/slides.md",
+			Title:  "Chapitre <1>",
+			Result: &Result{IndexPath: filepath.Join(outputDir, "chapter1", "presentation", "index.html")},
+		},
+		{
+			// Sans site extrait (--format zip), le lien pointe sur l'archive
+			Name:   "chapter2",
+			Entry:  "chapter2/slides.md",
+			Title:  "chapter2/slides.md",
+			Result: &Result{ArchivePath: filepath.Join(outputDir, "chapter2", "presentation.zip")},
+		},
+		{
+			Name:  "chapter3",
+			Entry: "chapter3/slides.md",
+			Title: "Chapitre 3",
+			Err:   errors.New("job failed"),
+		},
+	}
+
+	indexPath, err := writeDecksIndex(outputDir, decks)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(outputDir, "index.html"), indexPath)
+
+	index, err := os.ReadFile(indexPath)
+	require.NoError(t, err)
+	html := string(index)
+	assert.Contains(t, html, `<a href="chapter1/presentation/index.html">Chapitre &lt;1&gt;</a> <small>chapter1/slides.md</small>`)
+	assert.Contains(t, html, `<a href="chapter2/presentation.zip">chapter2/slides.md</a>`)
+	assert.Contains(t, html, `<span class="error">Chapitre 3 — échec: job failed</span>`)
+	assert.NotContains(t, html, `href="chapter3`)
+}
@@ -0,0 +1,230 @@
+package generator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// conventionalEntries sont les noms usuels du fichier principal, par priorité
+var conventionalEntries = []string{"slides.md", "deck.md", "presentation.md"}
+
+// slidevHeadmatterKeys sont des clés propres au frontmatter du fichier
+// principal d'une présentation Slidev
+var slidevHeadmatterKeys = []string{
+	"theme", "addons", "highlighter", "drawings", "transition",
+	"colorSchema", "aspectRatio", "canvasWidth", "fonts", "mdc",
+}
+
+// detectEntry détermine le fichier principal d'une présentation parmi les
+// fichiers names (chemins relatifs à baseDir) : entry s'il est fourni, sinon
+// celui des scripts slidev de package.json, un nom usuel (slides.md...),
+// l'unique fichier Markdown dont le frontmatter est celui d'une présentation,
+// ou l'unique fichier Markdown à la racine. Plusieurs candidats au même
+// niveau sont une erreur qui les liste, par ordre alphabétique.
+func detectEntry(baseDir string, names []string, entry string) (string, error) {
+	available := make(map[string]bool, len(names))
+	for _, name := range names {
+		available[name] = true
+	}
+
+	if entry != "" {
+		entry = path.Clean(filepath.ToSlash(entry))
+		if !available[entry] {
+			return "", entryError(fmt.Sprintf("fichier principal %s introuvable dans les sources", entry), baseDir, names)
+		}
+		return entry, nil
+	}
+
+	// Scripts slidev de package.json
+	if entries, err := packageScriptEntries(filepath.Join(baseDir, "package.json")); err == nil {
+		var candidates []string
+		for _, candidate := range entries {
+			if available[candidate] {
+				candidates = append(candidates, candidate)
+			}
+		}
+		switch {
+		case len(candidates) == 1:
+			return candidates[0], nil
+		case len(candidates) > 1:
+			return "", candidatesError("plusieurs présentations dans les scripts de package.json", candidates)
+		}
+	}
+
+	// Noms usuels
+	for _, candidate := range conventionalEntries {
+		if available[candidate] {
+			return candidate, nil
+		}
+	}
+
+	// Frontmatter
+	candidates := headmatterEntries(baseDir, names)
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) > 1:
+		return "", candidatesError("plusieurs présentations possibles", candidates)
+	}
+
+	// Unique fichier Markdown
+	candidates = markdownFiles(names, true)
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) > 1:
+		return "", candidatesError("plusieurs fichiers Markdown possibles", candidates)
+	}
+
+	return "", entryError("fichier principal introuvable", baseDir, names)
+}
+
+// entryError construit une erreur listant les fichiers principaux possibles
+func entryError(reason, baseDir string, names []string) error {
+	candidates := headmatterEntries(baseDir, names)
+	if len(candidates) == 0 {
+		candidates = markdownFiles(names, true)
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("%s: aucun fichier Markdown dans les sources", reason)
+	}
+	return candidatesError(reason, candidates)
+}
+
+// candidatesError construit une erreur listant des fichiers principaux
+// possibles, triés
+func candidatesError(reason string, candidates []string) error {
+	sorted := slices.Clone(candidates)
+	sort.Strings(sorted)
+	return fmt.Errorf("%s (candidats: %s) ; préciser le fichier avec --entry", reason, strings.Join(sorted, ", "))
+}
+
+// markdownFiles retourne les fichiers Markdown, hors README et CHANGELOG, à
+// la racine seulement si rootOnly
+func markdownFiles(names []string, rootOnly bool) []string {
+	var files []string
+	for _, name := range names {
+		if !strings.EqualFold(path.Ext(name), ".md") || (rootOnly && strings.Contains(name, "/")) {
+			continue
+		}
+		switch strings.ToUpper(strings.TrimSuffix(path.Base(name), path.Ext(name))) {
+		case "README", "CHANGELOG", "CONTRIBUTING", "LICENSE":
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// headmatterEntries retourne les fichiers Markdown à la racine dont le
+// frontmatter contient une clé de configuration Slidev
+func headmatterEntries(baseDir string, names []string) []string {
+	var entries []string
+	for _, name := range markdownFiles(names, true) {
+		frontmatter, err := readFrontmatter(filepath.Join(baseDir, filepath.FromSlash(name)))
+		if err != nil {
+			continue
+		}
+		for _, key := range slidevHeadmatterKeys {
+			if _, ok := frontmatter[key]; ok {
+				entries = append(entries, name)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// readFrontmatter lit le bloc YAML en tête d'un fichier Markdown (entre deux
+// lignes ---) ; retourne une map vide s'il n'y en a pas
+func readFrontmatter(file string) (map[string]interface{}, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	frontmatter := map[string]interface{}{}
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return frontmatter, scanner.Err()
+	}
+
+	var block strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "---" {
+			if err := yaml.Unmarshal([]byte(block.String()), &frontmatter); err != nil {
+				return nil, fmt.Errorf("frontmatter invalide dans %s: %w", file, err)
+			}
+			return frontmatter, nil
+		}
+		block.WriteString(line)
+		block.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Bloc non fermé : pas de frontmatter
+	return map[string]interface{}{}, nil
+}
+
+// packageScriptEntries retourne les fichiers Markdown passés à slidev dans
+// les scripts de package.json, triés et sans doublons
+func packageScriptEntries(packageFile string) ([]string, error) {
+	data, err := os.ReadFile(packageFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("package.json invalide: %w", err)
+	}
+
+	var entries []string
+	for _, script := range pkg.Scripts {
+		entries = append(entries, slidevScriptEntries(script)...)
+	}
+	sort.Strings(entries)
+	return slices.Compact(entries), nil
+}
+
+// slidevScriptEntries extrait les fichiers Markdown passés à slidev dans une
+// commande (ex: "slidev build talk.md --base /talk/")
+func slidevScriptEntries(script string) []string {
+	var entries []string
+	for _, command := range strings.FieldsFunc(script, func(r rune) bool {
+		return r == '&' || r == ';' || r == '|'
+	}) {
+		fields := strings.Fields(command)
+		for i, field := range fields {
+			if field != "slidev" && !strings.HasSuffix(field, "/slidev") {
+				continue
+			}
+			for _, arg := range fields[i+1:] {
+				arg = strings.Trim(arg, `"'`)
+				if !strings.HasPrefix(arg, "-") && strings.HasSuffix(strings.ToLower(arg), ".md") {
+					entries = append(entries, path.Clean(strings.TrimPrefix(arg, "./")))
+					break
+				}
+			}
+			break
+		}
+	}
+	return entries
+}
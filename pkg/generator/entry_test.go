@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slidevHeadmatter = "---\ntheme: seriph\n---\n\n# Title\n"
+
+func TestDetectEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		entry string // option --entry
+		want  string
+		err   string
+	}{
+		{
+			name:  "explicit entry",
+			files: map[string]string{"slides.md": "# A", "talk/intro.md": "# B"},
+			entry: "./talk/intro.md",
+			want:  "talk/intro.md",
+		},
+		{
+			name:  "explicit entry missing",
+			files: map[string]string{"slides.md": "# A"},
+			entry: "talk.md",
+			err:   "fichier principal talk.md introuvable dans les sources (candidats: slides.md)",
+		},
+		{
+			name: "package.json script",
+			files: map[string]string{
+				"package.json": `{"scripts": {"dev": "slidev talk.md --open", "build": "slidev build talk.md"}}`,
+				"talk.md":      "# Talk",
+				"slides.md":    "# Slides",
+			},
+			want: "talk.md",
+		},
+		{
+			name: "package.json script with path",
+			files: map[string]string{
+				"package.json":    `{"scripts": {"build": "npx slidev build ./decks/intro.md && echo done"}}`,
+				"decks/intro.md":  "# Intro",
+				"presentation.md": "# Other",
+			},
+			want: "decks/intro.md",
+		},
+		{
+			name: "package.json scripts disagree",
+			files: map[string]string{
+				"package.json": `{"scripts": {"dev:b": "slidev b.md", "dev:a": "slidev a.md", "build": "slidev build b.md"}}`,
+				"a.md":         "# A",
+				"b.md":         "# B",
+			},
+			err: "plusieurs présentations dans les scripts de package.json (candidats: a.md, b.md)",
+		},
+		{
+			name: "package.json script file missing",
+			files: map[string]string{
+				"package.json": `{"scripts": {"dev": "slidev missing.md"}}`,
+				"deck.md":      "# Deck",
+			},
+			want: "deck.md",
+		},
+		{
+			name:  "conventional name",
+			files: map[string]string{"deck.md": "# Deck", "notes.md": slidevHeadmatter},
+			want:  "deck.md",
+		},
+		{
+			name:  "conventional names by priority",
+			files: map[string]string{"presentation.md": "# P", "slides.md": "# S", "deck.md": "# D"},
+			want:  "slides.md",
+		},
+		{
+			name:  "frontmatter",
+			files: map[string]string{"talk.md": slidevHeadmatter, "notes.md": "---\ntitle: Notes\n---\n", "README.md": "# Readme"},
+			want:  "talk.md",
+		},
+		{
+			name:  "several frontmatters",
+			files: map[string]string{"b.md": slidevHeadmatter, "a.md": slidevHeadmatter, "notes.md": "# Notes"},
+			err:   "plusieurs présentations possibles (candidats: a.md, b.md)",
+		},
+		{
+			name:  "single markdown file",
+			files: map[string]string{"talk.md": "# Talk", "README.md": "# Readme", "pages/part.md": "# Part"},
+			want:  "talk.md",
+		},
+		{
+			name:  "several markdown files",
+			files: map[string]string{"z.md": "# Z", "a.md": "# A", "m.md": "# M"},
+			err:   "plusieurs fichiers Markdown possibles (candidats: a.md, m.md, z.md)",
+		},
+		{
+			name:  "no markdown file",
+			files: map[string]string{"README.md": "# Readme", "public/logo.svg": "<svg/>"},
+			err:   "fichier principal introuvable: aucun fichier Markdown dans les sources",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSources(t, tt.files)
+			names := make([]string, 0, len(tt.files))
+			for name := range tt.files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			entry, err := detectEntry(dir, names, tt.entry)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, entry)
+		})
+	}
+}
+
+func TestSlidevScriptEntries(t *testing.T) {
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{script: "slidev", want: nil},
+		{script: "slidev build talk.md --base /talk/", want: []string{"talk.md"}},
+		{script: "slidev --open ./slides/intro.md", want: []string{"slides/intro.md"}},
+		{script: `node_modules/.bin/slidev export "deck.md"`, want: []string{"deck.md"}},
+		{script: "slidev build a.md && slidev build b.md; echo c.md", want: []string{"a.md", "b.md"}},
+		{script: "vite build index.md", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			assert.Equal(t, tt.want, slidevScriptEntries(tt.script))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	ocfworker "ocf-worker-sdk"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+)
+
+const (
+	// fingerprintVersion change si le calcul de l'empreinte évolue
+	fingerprintVersion = "v2"
+	// fingerprintPageSize et fingerprintMaxPages bornent la recherche d'un
+	// job précédent parmi les jobs terminés
+	fingerprintPageSize = 100
+	fingerprintMaxPages = 5
+)
+
+// sourceFingerprint calcule l'empreinte d'une génération : le nom et le
+// contenu des fichiers uploadés, les packages npm, les formats de résultats
+// et le fichier principal. Deux générations de même empreinte produisent la
+// même présentation.
+func sourceFingerprint(uploads []ocfworker.StreamUpload, packages, formats []string, sourcePath string) (string, error) {
+	sorted := make([]ocfworker.StreamUpload, len(uploads))
+	copy(sorted, uploads)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "ocf-fingerprint-%s\nsource=%s\n", fingerprintVersion, sourcePath)
+
+	for _, upload := range sorted {
+		sum, err := hashUpload(upload)
+		if err != nil {
+			return "", fmt.Errorf("empreinte de %s: %w", upload.Name, err)
+		}
+		fmt.Fprintf(hash, "file=%s %x\n", upload.Name, sum)
+	}
+
+	for _, pkg := range sortedUnique(packages) {
+		fmt.Fprintf(hash, "package=%s\n", pkg)
+	}
+	for _, format := range sortedUnique(formats) {
+		fmt.Fprintf(hash, "format=%s\n", format)
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// sortedUnique retourne une copie triée et sans doublons de values
+func sortedUnique(values []string) []string {
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return slices.Compact(sorted)
+}
+
+// hashUpload calcule le SHA-256 du contenu d'un fichier à uploader
+func hashUpload(upload ocfworker.StreamUpload) ([]byte, error) {
+	reader, err := upload.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// findPreviousJob cherche parmi les jobs terminés le plus récent ayant la
+// même empreinte ; retourne nil s'il n'y en a pas
+func (g *Generator) findPreviousJob(ctx context.Context, fingerprint string) (*models.JobResponse, error) {
+	var found *models.JobResponse
+
+	offset := 0
+	for page := 0; page < fingerprintMaxPages; page++ {
+		jobs, err := g.client.Jobs.List(ctx, &ocfworker.ListJobsOptions{
+			Status: string(models.StatusCompleted),
+			Limit:  fingerprintPageSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range jobs.Jobs {
+			job := &jobs.Jobs[i]
+			if job.Status != models.StatusCompleted || job.Metadata["fingerprint"] != fingerprint {
+				continue
+			}
+			if found == nil || job.CreatedAt.After(found.CreatedAt) {
+				found = job
+			}
+		}
+
+		offset += len(jobs.Jobs)
+		if len(jobs.Jobs) == 0 || (jobs.TotalCount > 0 && offset >= jobs.TotalCount) {
+			break
+		}
+	}
+
+	return found, nil
+}
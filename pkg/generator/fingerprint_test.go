@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	ocfworker "ocf-worker-sdk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUploads crée des uploads depuis des contenus en mémoire, dans l'ordre donné
+func memoryUploads(files ...string) []ocfworker.StreamUpload {
+	uploads := make([]ocfworker.StreamUpload, 0, len(files)/2)
+	for i := 0; i < len(files); i += 2 {
+		content := files[i+1]
+		uploads = append(uploads, ocfworker.StreamUpload{
+			Name: files[i],
+			Size: int64(len(content)),
+			Open: func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(content)), nil
+			},
+		})
+	}
+	return uploads
+}
+
+func TestSourceFingerprint(t *testing.T) {
+	fingerprint := func(uploads []ocfworker.StreamUpload, packages, formats []string, entry string) string {
+		t.Helper()
+		sum, err := sourceFingerprint(uploads, packages, formats, entry)
+		require.NoError(t, err)
+		return sum
+	}
+
+	uploads := memoryUploads("slides.md", "# Hello", "public/logo.svg", "<svg/>")
+	packages := []string{"@slidev/theme-seriph", "slidev-addon-qrcode"}
+	formats := []string{FormatHTML, FormatZIP}
+	base := fingerprint(uploads, packages, formats, "slides.md")
+	assert.True(t, strings.HasPrefix(base, "sha256:"))
+
+	t.Run("stable regardless of order", func(t *testing.T) {
+		reordered := memoryUploads("public/logo.svg", "<svg/>", "slides.md", "# Hello")
+		assert.Equal(t, base, fingerprint(reordered, []string{"slidev-addon-qrcode", "@slidev/theme-seriph", "slidev-addon-qrcode"}, []string{FormatZIP, FormatHTML}, "slides.md"))
+	})
+
+	tests := []struct {
+		name     string
+		uploads  []ocfworker.StreamUpload
+		packages []string
+		formats  []string
+		entry    string
+	}{
+		{name: "content", uploads: memoryUploads("slides.md", "# Hello!", "public/logo.svg", "<svg/>"), packages: packages, formats: formats, entry: "slides.md"},
+		{name: "file name", uploads: memoryUploads("intro.md", "# Hello", "public/logo.svg", "<svg/>"), packages: packages, formats: formats, entry: "slides.md"},
+		{name: "added file", uploads: memoryUploads("slides.md", "# Hello", "public/logo.svg", "<svg/>", "notes.md", ""), packages: packages, formats: formats, entry: "slides.md"},
+		{name: "entry", uploads: uploads, packages: packages, formats: formats, entry: "public/logo.svg"},
+		{name: "packages", uploads: uploads, packages: []string{"@slidev/theme-seriph"}, formats: formats, entry: "slides.md"},
+		{name: "package version", uploads: uploads, packages: []string{"@slidev/theme-seriph@0.25.0", "slidev-addon-qrcode"}, formats: formats, entry: "slides.md"},
+		{name: "formats", uploads: uploads, packages: packages, formats: []string{FormatHTML, FormatZIP, FormatTAR}, entry: "slides.md"},
+	}
+	for _, tt := range tests {
+		t.Run("changes with "+tt.name, func(t *testing.T) {
+			assert.NotEqual(t, base, fingerprint(tt.uploads, tt.packages, tt.formats, tt.entry))
+		})
+	}
+}
+
+func TestSourceFingerprint_OpenError(t *testing.T) {
+	uploads := []ocfworker.StreamUpload{{
+		Name: "slides.md",
+		Open: func() (io.ReadCloser, error) { return nil, io.ErrUnexpectedEOF },
+	}}
+	_, err := sourceFingerprint(uploads, nil, nil, "slides.md")
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Contains(t, err.Error(), "slides.md")
+}
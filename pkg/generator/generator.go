@@ -3,9 +3,12 @@ package generator
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	ocfworker "ocf-worker-sdk"
@@ -16,10 +19,11 @@ import (
 
 // Generator gère la génération de présentations Slidev
 type Generator struct {
-	client     *ocfworker.Client
-	downloader *GitHubDownloader
-	config     *Config
-	logger     *log.Logger
+	client    *ocfworker.Client
+	providers []SourceProvider
+	cache     *Cache
+	config    *Config
+	logger    *log.Logger
 }
 
 // New crée un nouveau générateur
@@ -33,6 +37,14 @@ func New(config *Config) *Generator {
 		clientOpts = append(clientOpts, ocfworker.WithAuth(config.AuthToken))
 	}
 
+	if config.TLSCertFile != "" {
+		clientOpts = append(clientOpts, ocfworker.WithClientCertificate(config.TLSCertFile, config.TLSKeyFile))
+	}
+
+	if config.TLSCAFile != "" {
+		clientOpts = append(clientOpts, ocfworker.WithRootCAs(config.TLSCAFile))
+	}
+
 	if config.Verbose {
 		clientOpts = append(clientOpts, ocfworker.WithLogger(NewVerboseLogger()))
 	}
@@ -44,56 +56,171 @@ func New(config *Config) *Generator {
 		logger.SetOutput(os.Stderr)
 	}
 
+	var cache *Cache
+	if !config.NoCache {
+		var err error
+		if cache, err = NewCache(config.CacheDir, config.CacheMaxSize); err != nil {
+			logger.Printf("⚠️ Cache des sources désactivé: %v", err)
+		}
+	}
+
 	return &Generator{
-		client:     client,
-		downloader: NewGitHubDownloader(),
-		config:     config,
-		logger:     logger,
+		client:    client,
+		providers: DefaultProviders(),
+		cache:     cache,
+		config:    config,
+		logger:    logger,
 	}
 }
 
-// Generate génère une présentation Slidev
+// Generate génère une présentation Slidev, ou une présentation par deck
+// si plusieurs sont demandés (voir Config.Decks et Config.AllDecks)
 func (g *Generator) Generate(ctx context.Context) (*Result, error) {
-	g.logger.Printf("🚀 Début de la génération depuis: %s", g.config.GitHubURL)
+	g.logger.Printf("🚀 Début de la génération depuis: %s", g.config.Source())
 
 	// 1. Vérifier la santé du service
 	if err := g.checkHealth(ctx); err != nil {
 		return nil, fmt.Errorf("service indisponible: %w", err)
 	}
 
-	// 2. Générer les IDs
-	jobID := uuid.New()
-	courseID := uuid.New()
-
-	g.logger.Printf("🆔 Job ID: %s", jobID)
-	g.logger.Printf("🆔 Course ID: %s", courseID)
-
-	// 3. Télécharger le dépôt
-	tempDir := filepath.Join(os.TempDir(), fmt.Sprintf("ocf-slidev-%s", jobID.String()[:8]))
-	defer os.RemoveAll(tempDir)
+	// 2. Récupérer les sources
+	sources, err := g.fetchSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer sources.cleanup()
 
-	files, err := g.downloader.DownloadRepo(ctx, g.config.GitHubURL, tempDir, g.config.Subfolder)
+	// 3. Compléter la configuration par le manifeste des sources
+	gen, err := g.applyManifest(sources)
 	if err != nil {
-		return nil, fmt.Errorf("téléchargement échoué: %w", err)
+		return nil, err
 	}
 
-	if len(files) == 0 {
+	if len(sources.files) == 0 {
 		return nil, fmt.Errorf("aucun fichier Slidev trouvé")
 	}
 
 	// 4. Préparer les uploads
-	uploads, err := g.prepareUploads(files)
+	uploads, err := gen.prepareUploads(sources.dir, sources.files)
 	if err != nil {
 		return nil, fmt.Errorf("préparation uploads échouée: %w", err)
 	}
 
-	// 5. Upload des sources
+	names := make([]string, len(uploads))
+	for i, upload := range uploads {
+		names[i] = upload.Name
+	}
+
+	if gen.config.IsMultiDeck() {
+		return gen.generateDecks(ctx, sources, uploads, names)
+	}
+
+	entry, err := detectEntry(sources.dir, names, gen.config.Entry)
+	if err != nil {
+		return nil, err
+	}
+	gen.logger.Printf("📄 Fichier principal: %s", entry)
+
+	result, err := gen.generateDeck(ctx, sources, uploads, entry, gen.config.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	gen.logger.Printf("🎉 Génération terminée avec succès!")
+	return result, nil
+}
+
+// applyManifest lit les manifestes ocf.yaml des sources et retourne un
+// générateur dont la configuration en est complétée. Le manifeste de la
+// racine est lu avant de restreindre les sources à leur sous-dossier (celui
+// de l'URL et de --subfolder, ou à défaut celui du manifeste), puis celui du
+// sous-dossier : pour chaque réglage, la configuration prime sur le manifeste
+// du sous-dossier, qui prime sur celui de la racine. Les motifs
+// include/exclude restreignent ensuite les fichiers du sous-dossier.
+func (g *Generator) applyManifest(sources *fetchedSources) (*Generator, error) {
+	root := sources.root
+	var err error
+	if !sources.narrowed {
+		if root, err = loadManifest(sources.dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var sub *Manifest
+	subfolder := sources.subfolder
+	if subfolder == "" && root != nil {
+		subfolder = JoinSubPath("", root.Subfolder)
+	}
+	if subfolder != "" {
+		// Les sources récupérées sont parfois déjà celles du sous-dossier
+		if !sources.narrowed {
+			if err := sources.narrow(subfolder); err != nil {
+				if sources.subfolder == "" {
+					return nil, fmt.Errorf("manifeste %s invalide: %w", root.File, err)
+				}
+				return nil, err
+			}
+		}
+
+		if sub, err = loadManifest(sources.dir); err != nil {
+			return nil, fmt.Errorf("%s: %w", subfolder, err)
+		}
+		if sub != nil {
+			sub.File = path.Join(subfolder, sub.File)
+			if nested := JoinSubPath("", sub.Subfolder); nested != "" && g.config.Subfolder == "" {
+				if err := sources.narrow(nested); err != nil {
+					return nil, fmt.Errorf("manifeste %s invalide: %w", sub.File, err)
+				}
+			}
+		}
+	}
+
+	config := *g.config
+	for _, manifest := range []*Manifest{sub, root} {
+		if manifest != nil {
+			g.logger.Printf("📋 Manifeste %s", manifest.File)
+			config = *manifest.apply(&config)
+		}
+	}
+	config.applyDefaults()
+
+	sources.files = filterFiles(sources.dir, sources.files, config.Include, config.Exclude)
+
+	gen := *g
+	gen.config = &config
+	return &gen, nil
+}
+
+// generateDeck génère la présentation d'un fichier principal à partir des
+// sources déjà préparées, et en télécharge les résultats dans outputDir
+func (g *Generator) generateDeck(ctx context.Context, sources *fetchedSources, uploads []ocfworker.StreamUpload, entry, outputDir string) (*Result, error) {
+	packages := g.deckPackages(sources.dir, entry)
+
+	// Réutiliser un job précédent si les sources n'ont pas changé
+	fingerprint, err := sourceFingerprint(uploads, packages, g.config.Formats, entry)
+	if err != nil {
+		return nil, fmt.Errorf("empreinte des sources échouée: %w", err)
+	}
+	if !g.config.Force {
+		if result := g.reusePreviousJob(ctx, fingerprint, outputDir); result != nil {
+			result.Entry = entry
+			return result, nil
+		}
+	}
+
+	jobID := uuid.New()
+	courseID := uuid.New()
+
+	g.logger.Printf("🆔 Job ID: %s", jobID)
+	g.logger.Printf("🆔 Course ID: %s", courseID)
+
+	// Upload des sources
 	if err := g.uploadSources(ctx, jobID.String(), uploads); err != nil {
 		return nil, fmt.Errorf("upload échoué: %w", err)
 	}
 
-	// 6. Génération
-	_, err = g.createAndWaitJob(ctx, jobID, courseID)
+	// Génération
+	_, err = g.createAndWaitJob(ctx, jobID, courseID, sources, entry, packages, fingerprint)
 	if err != nil {
 		logs, errLogs := g.client.Storage.GetLogs(ctx, jobID.String())
 		if errLogs != nil {
@@ -103,19 +230,45 @@ func (g *Generator) Generate(ctx context.Context) (*Result, error) {
 		return nil, fmt.Errorf("génération échouée: %w", err)
 	}
 
-	// 7. Téléchargement des résultats
-	result, err := g.downloadResults(ctx, courseID.String())
+	// Téléchargement des résultats
+	result, err := g.downloadResults(ctx, courseID.String(), outputDir)
 	if err != nil {
 		return nil, fmt.Errorf("téléchargement résultats échoué: %w", err)
 	}
 
 	result.JobID = jobID.String()
 	result.CourseID = courseID.String()
-
-	g.logger.Printf("🎉 Génération terminée avec succès!")
+	result.Entry = entry
 	return result, nil
 }
 
+// reusePreviousJob télécharge les résultats d'un job terminé de même
+// empreinte. Retourne nil pour lancer une nouvelle génération si aucun job ne
+// correspond ou si ses résultats ne sont plus disponibles.
+func (g *Generator) reusePreviousJob(ctx context.Context, fingerprint, outputDir string) *Result {
+	previous, err := g.findPreviousJob(ctx, fingerprint)
+	if err != nil {
+		g.logger.Printf("⚠️ Recherche d'un job précédent échouée: %v", err)
+		return nil
+	}
+	if previous == nil {
+		return nil
+	}
+
+	g.logger.Printf("♻️ Sources inchangées: réutilisation du job %s", previous.ID)
+
+	result, err := g.downloadResults(ctx, previous.CourseID.String(), outputDir)
+	if err != nil {
+		g.logger.Printf("⚠️ Résultats du job %s indisponibles, nouvelle génération: %v", previous.ID, err)
+		return nil
+	}
+
+	result.JobID = previous.ID.String()
+	result.CourseID = previous.CourseID.String()
+	result.Reused = true
+	return result
+}
+
 func (g *Generator) checkHealth(ctx context.Context) error {
 	g.logger.Printf("🏥 Vérification de la santé du service...")
 
@@ -128,33 +281,233 @@ func (g *Generator) checkHealth(ctx context.Context) error {
 	return nil
 }
 
-func (g *Generator) prepareUploads(files []string) ([]ocfworker.FileUpload, error) {
+// fetchedSources décrit les sources récupérées pour un job
+type fetchedSources struct {
+	// dir est le répertoire auquel les chemins de files sont relatifs
+	dir   string
+	files []string
+	// subfolder est le sous-dossier de la présentation (URL et --subfolder),
+	// appliqué par applyManifest une fois le manifeste de la racine lu
+	subfolder string
+	// narrowed indique que seul subfolder a été récupéré : dir en est déjà
+	// le répertoire, et root est le manifeste de la racine lu à part
+	narrowed bool
+	root     *Manifest
+	// provider et source décrivent le dépôt distant (vides en local)
+	provider string
+	source   *Source
+	cleanup  func()
+}
+
+// narrow restreint les sources à l'un de leurs sous-dossiers
+func (s *fetchedSources) narrow(subfolder string) error {
+	subfolder = path.Clean(filepath.ToSlash(subfolder))
+	if subfolder == ".." || strings.HasPrefix(subfolder, "../") || path.IsAbs(subfolder) {
+		return fmt.Errorf("sous-dossier %s hors des sources", subfolder)
+	}
+
+	dir := filepath.Join(s.dir, filepath.FromSlash(subfolder))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("sous-dossier %s introuvable dans les sources", subfolder)
+	}
+
+	files := make([]string, 0, len(s.files))
+	for _, file := range s.files {
+		if strings.HasPrefix(file, dir+string(filepath.Separator)) {
+			files = append(files, file)
+		}
+	}
+	s.dir = dir
+	s.files = files
+	return nil
+}
+
+// fetchSources récupère les fichiers à uploader. Une source locale est lue
+// sur place, sans téléchargement ni copie, depuis sa racine : le sous-dossier
+// n'est appliqué qu'après lecture du manifeste (voir applyManifest). Un dépôt
+// distant est résolu en commit avant d'en télécharger uniquement ce commit,
+// et seulement son sous-dossier si le fournisseur sait lire à part le
+// manifeste de la racine (voir FileReader).
+func (g *Generator) fetchSources(ctx context.Context) (*fetchedSources, error) {
+	if g.config.IsLocal() {
+		dir := g.config.SourceDir
+		g.logger.Printf("📂 Lecture des sources locales: %s", filepath.Join(dir, g.config.Subfolder))
+
+		files, err := collectLocalFiles(dir)
+		if err != nil {
+			return nil, fmt.Errorf("lecture des sources échouée: %w", err)
+		}
+		return &fetchedSources{
+			dir:       dir,
+			files:     files,
+			subfolder: JoinSubPath("", g.config.Subfolder),
+			cleanup:   func() {},
+		}, nil
+	}
+
+	provider, source, err := SelectProvider(g.providers, g.config.RepoURL, g.config.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if g.config.Ref != "" {
+		source.Ref = g.config.Ref
+	}
+	source.Path = JoinSubPath(source.Path, g.config.Subfolder)
+
+	source.Auth = g.config.credentialsFor(provider.Name(), source)
+
+	// Sans identifiants, les credential helpers de git ne sont consultés que
+	// si le dépôt refuse l'accès anonyme
+	err = provider.Resolve(ctx, source)
+	if isAuthError(err) && source.Auth == nil {
+		if source.Auth = gitCredentialFill(ctx, source.CloneURL); source.Auth != nil {
+			g.logger.Printf("🔑 Identifiants trouvés via git credential")
+			err = provider.Resolve(ctx, source)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("résolution de la référence échouée: %w", err)
+	}
+
+	// Seul le sous-dossier est récupéré si le manifeste de la racine peut
+	// être lu à part ; sinon le dépôt est récupéré depuis sa racine
+	repo := *source
+	var root *Manifest
+	reader, narrowed := provider.(FileReader)
+	narrowed = narrowed && source.Path != ""
+	if narrowed {
+		root, err = findManifest(func(name string) ([]byte, error) {
+			return reader.ReadFile(ctx, source, name)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("lecture du manifeste de la racine échouée: %w", err)
+		}
+	} else {
+		repo.Path = ""
+	}
+
+	var sources *fetchedSources
+	if g.cache != nil {
+		sources, err = g.fetchCached(ctx, provider, &repo)
+	} else {
+		sources, err = g.fetchRemote(ctx, provider, &repo)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sources.source = source
+	sources.subfolder = source.Path
+	sources.narrowed = narrowed
+	sources.root = root
+	return sources, nil
+}
+
+// fetchRemote télécharge les sources dans un répertoire temporaire
+func (g *Generator) fetchRemote(ctx context.Context, provider SourceProvider, source *Source) (*fetchedSources, error) {
+	g.logger.Printf("📥 Téléchargement depuis %s: %s (commit %.12s)", provider.Name(), source, source.Commit)
+
+	tempDir, err := os.MkdirTemp("", "ocf-slidev-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	files, err := provider.Fetch(ctx, source, tempDir)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("téléchargement échoué: %w", err)
+	}
+
+	return &fetchedSources{
+		dir:      tempDir,
+		files:    files,
+		provider: provider.Name(),
+		source:   source,
+		cleanup:  cleanup,
+	}, nil
+}
+
+// fetchCached récupère les sources depuis le cache, ou les y télécharge
+func (g *Generator) fetchCached(ctx context.Context, provider SourceProvider, source *Source) (*fetchedSources, error) {
+	entry, ok := g.cache.Get(provider.Name(), source)
+	if ok {
+		g.logger.Printf("⚡ Sources en cache: %s (commit %.12s)", source, source.Commit)
+	} else {
+		g.logger.Printf("📥 Téléchargement depuis %s: %s (commit %.12s)", provider.Name(), source, source.Commit)
+
+		var err error
+		entry, err = g.cache.Put(provider.Name(), source, func(dir string) ([]string, error) {
+			return provider.Fetch(ctx, source, dir)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("téléchargement échoué: %w", err)
+		}
+	}
+
+	files, err := collectLocalFiles(entry.FilesDir())
+	if err != nil {
+		return nil, fmt.Errorf("lecture du cache échouée: %w", err)
+	}
+
+	return &fetchedSources{
+		dir:      entry.FilesDir(),
+		files:    files,
+		provider: provider.Name(),
+		source:   source,
+		cleanup:  g.pruneCache,
+	}, nil
+}
+
+// pruneCache borne la taille du cache, une fois les sources uploadées
+func (g *Generator) pruneCache() {
+	if g.cache.MaxSize <= 0 {
+		return
+	}
+
+	removed, err := g.cache.Prune(g.cache.MaxSize)
+	if err != nil {
+		g.logger.Printf("⚠️ Nettoyage du cache échoué: %v", err)
+		return
+	}
+	if len(removed) > 0 {
+		g.logger.Printf("🧹 %d entrée(s) retirée(s) du cache", len(removed))
+	}
+}
+
+// prepareUploads prépare l'upload des fichiers en conservant leur chemin
+// relatif à baseDir (ex: components/Counter.vue)
+func (g *Generator) prepareUploads(baseDir string, files []string) ([]ocfworker.StreamUpload, error) {
 	g.logger.Printf("📦 Préparation de %d fichiers...", len(files))
 
-	var uploads []ocfworker.FileUpload
+	uploads := make([]ocfworker.StreamUpload, 0, len(files))
 	for _, file := range files {
-		content, err := os.ReadFile(file)
+		info, err := os.Stat(file)
 		if err != nil {
 			return nil, fmt.Errorf("lecture fichier %s: %w", file, err)
 		}
 
-		path := strings.Split(file, string(os.PathSeparator))
-		realPath := path[3:]
+		name, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			return nil, fmt.Errorf("chemin du fichier %s: %w", file, err)
+		}
 
-		uploads = append(uploads, ocfworker.FileUpload{
-			Name:        filepath.Join(realPath...),
-			Content:     content,
+		uploads = append(uploads, ocfworker.StreamUpload{
+			Name:        filepath.ToSlash(name),
+			Size:        info.Size(),
 			ContentType: detectContentType(file),
+			Open: func() (io.ReadCloser, error) {
+				return os.Open(file)
+			},
 		})
 	}
 
 	return uploads, nil
 }
 
-func (g *Generator) uploadSources(ctx context.Context, jobID string, uploads []ocfworker.FileUpload) error {
+func (g *Generator) uploadSources(ctx context.Context, jobID string, uploads []ocfworker.StreamUpload) error {
 	g.logger.Printf("📤 Upload de %d fichiers...", len(uploads))
 
-	result, err := g.client.Storage.UploadSources(ctx, jobID, uploads)
+	result, err := g.client.Storage.UploadSourcesStream(ctx, jobID, uploads)
 	if err != nil {
 		return err
 	}
@@ -163,19 +516,32 @@ func (g *Generator) uploadSources(ctx context.Context, jobID string, uploads []o
 	return nil
 }
 
-func (g *Generator) createAndWaitJob(ctx context.Context, jobID, courseID uuid.UUID) (*models.JobResponse, error) {
+func (g *Generator) createAndWaitJob(ctx context.Context, jobID, courseID uuid.UUID, sources *fetchedSources, entry string, packages []string, fingerprint string) (*models.JobResponse, error) {
 	g.logger.Printf("🚀 Création du job de génération...")
 
+	// Les clés renseignées par le générateur priment sur Config.Metadata
+	metadata := make(map[string]interface{}, len(g.config.Metadata)+6)
+	for key, value := range g.config.Metadata {
+		metadata[key] = value
+	}
+	metadata["generator"] = "ocf-worker-cli"
+	metadata["source"] = "local"
+	metadata["fingerprint"] = fingerprint
+	if sources.source != nil {
+		metadata["source"] = sources.provider
+		metadata["url"] = sources.source.URL
+		metadata["commit"] = sources.source.Commit
+		if sources.source.Ref != "" {
+			metadata["ref"] = sources.source.Ref
+		}
+	}
+
 	req := &models.GenerationRequest{
 		JobID:      jobID,
 		CourseID:   courseID,
-		SourcePath: "slides.md", // Fichier principal par défaut
-		Metadata: map[string]interface{}{
-			"generator": "ocf-worker-cli",
-			"source":    "github",
-			"url":       g.config.GitHubURL,
-		},
-		Packages: g.config.NpmPackages,
+		SourcePath: entry,
+		Metadata:   metadata,
+		Packages:   packages,
 	}
 
 	waitOpts := &ocfworker.WaitOptions{
@@ -192,57 +558,88 @@ func (g *Generator) createAndWaitJob(ctx context.Context, jobID, courseID uuid.U
 	return job, nil
 }
 
-func (g *Generator) downloadResults(ctx context.Context, courseID string) (*Result, error) {
+// downloadResults télécharge dans outputDir les résultats d'un cours selon
+// Config.Formats : le site extrait (html), son archive ZIP (zip) et une
+// archive TAR (tar)
+func (g *Generator) downloadResults(ctx context.Context, courseID, outputDir string) (*Result, error) {
 	g.logger.Printf("📥 Téléchargement des résultats...")
 
 	// Créer le répertoire de sortie
-	if err := os.MkdirAll(g.config.OutputDir, 0755); err != nil {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return nil, fmt.Errorf("création répertoire sortie: %w", err)
 	}
 
-	// Télécharger l'archive
-	archiveOpts := &ocfworker.DownloadArchiveOptions{
-		Format:   "zip",
-		Compress: &[]bool{true}[0],
+	result := &Result{OutputDir: outputDir}
+	formats := g.config.Formats
+	if len(formats) == 0 {
+		formats = DefaultFormats
 	}
 
-	reader, err := g.client.Archive.DownloadArchive(ctx, courseID, archiveOpts)
-	if err != nil {
-		return nil, err
+	// L'archive ZIP sert aussi à extraire le site
+	if slices.Contains(formats, FormatHTML) || slices.Contains(formats, FormatZIP) {
+		archivePath := filepath.Join(outputDir, "presentation.zip")
+		if err := g.downloadArchive(ctx, courseID, FormatZIP, archivePath); err != nil {
+			return nil, err
+		}
+		result.ArchivePath = archivePath
+
+		if slices.Contains(formats, FormatHTML) {
+			// Extraire l'archive
+			extractDir := filepath.Join(outputDir, "presentation")
+			files, err := extractZipFile(archivePath, extractDir)
+			if err != nil {
+				return nil, fmt.Errorf("extraction archive: %w", err)
+			}
+
+			g.logger.Printf("✅ Présentation extraite: %s", extractDir)
+
+			// Chercher l'index.html principal
+			indexPath := filepath.Join(extractDir, "index.html")
+			if _, err := os.Stat(indexPath); err != nil {
+				// Chercher dans les sous-dossiers
+				matches, _ := filepath.Glob(filepath.Join(extractDir, "**/index.html"))
+				if len(matches) > 0 {
+					indexPath = matches[0]
+				}
+			}
+			result.IndexPath = indexPath
+			result.Files = files
+		}
+
+		if !slices.Contains(formats, FormatZIP) {
+			os.Remove(archivePath)
+			result.ArchivePath = ""
+		}
 	}
-	defer reader.Close()
 
-	// Sauvegarder l'archive
-	archivePath := filepath.Join(g.config.OutputDir, "presentation.zip")
-	if err := saveReaderToFile(reader, archivePath); err != nil {
-		return nil, fmt.Errorf("sauvegarde archive: %w", err)
+	if slices.Contains(formats, FormatTAR) {
+		tarPath := filepath.Join(outputDir, "presentation.tar.gz")
+		if err := g.downloadArchive(ctx, courseID, FormatTAR, tarPath); err != nil {
+			return nil, err
+		}
+		result.TarPath = tarPath
 	}
 
-	g.logger.Printf("✅ Archive: %s", archivePath)
+	return result, nil
+}
 
-	// Extraire l'archive
-	extractDir := filepath.Join(g.config.OutputDir, "presentation")
-	files, err := extractZipFile(archivePath, extractDir)
-	if err != nil {
-		return nil, fmt.Errorf("extraction archive: %w", err)
+// downloadArchive télécharge l'archive compressée d'un cours dans file
+func (g *Generator) downloadArchive(ctx context.Context, courseID, format, file string) error {
+	archiveOpts := &ocfworker.DownloadArchiveOptions{
+		Format:   format,
+		Compress: &[]bool{true}[0],
 	}
 
-	g.logger.Printf("✅ Présentation extraite: %s", extractDir)
+	reader, err := g.client.Archive.DownloadArchive(ctx, courseID, archiveOpts)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
 
-	// Chercher l'index.html principal
-	indexPath := filepath.Join(extractDir, "index.html")
-	if _, err := os.Stat(indexPath); err != nil {
-		// Chercher dans les sous-dossiers
-		matches, _ := filepath.Glob(filepath.Join(extractDir, "**/index.html"))
-		if len(matches) > 0 {
-			indexPath = matches[0]
-		}
+	if err := saveReaderToFile(reader, file); err != nil {
+		return fmt.Errorf("sauvegarde archive: %w", err)
 	}
 
-	return &Result{
-		OutputDir:   g.config.OutputDir,
-		IndexPath:   indexPath,
-		ArchivePath: archivePath,
-		Files:       files,
-	}, nil
+	g.logger.Printf("✅ Archive: %s", file)
+	return nil
 }
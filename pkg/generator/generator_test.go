@@ -0,0 +1,409 @@
+package generator
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ocfworker "ocf-worker-sdk"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorker simule l'API d'un worker : les jobs se terminent dès leur
+// création, et l'archive d'un cours contient un index.html
+type fakeWorker struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	jobs []*models.JobResponse
+	// failEntry fait échouer les jobs de ce fichier principal
+	failEntry string
+	// uploads compte les uploads de sources
+	uploads atomic.Int32
+	// sources liste les fichiers uploadés pour chaque job
+	sources map[string][]string
+}
+
+func newFakeWorker(t *testing.T) *fakeWorker {
+	worker := &fakeWorker{sources: map[string][]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		ocfworker.RespondJSON(w, http.StatusOK, ocfworker.MockHealthResponse("healthy"))
+	})
+	mux.HandleFunc("POST /api/v1/storage/jobs/{id}/sources", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(32<<20))
+		worker.uploads.Add(1)
+		worker.mu.Lock()
+		for _, file := range r.MultipartForm.File["files"] {
+			// FileHeader.Filename ne garde que le nom de base du fichier
+			_, params, err := mime.ParseMediaType(file.Header.Get("Content-Disposition"))
+			require.NoError(t, err)
+			worker.sources[r.PathValue("id")] = append(worker.sources[r.PathValue("id")], params["filename"])
+		}
+		worker.mu.Unlock()
+		ocfworker.RespondJSON(w, http.StatusCreated, &models.FileUploadResponse{Count: len(r.MultipartForm.File["files"])})
+	})
+	mux.HandleFunc("POST /api/v1/generate", func(w http.ResponseWriter, r *http.Request) {
+		var req models.GenerationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		job := &models.JobResponse{
+			ID:         req.JobID,
+			CourseID:   req.CourseID,
+			Status:     models.StatusCompleted,
+			SourcePath: req.SourcePath,
+			Metadata:   req.Metadata,
+			CreatedAt:  time.Now(),
+		}
+		worker.mu.Lock()
+		if req.SourcePath == worker.failEntry {
+			job.Status = models.StatusFailed
+			job.Error = "build failed"
+		}
+		worker.jobs = append(worker.jobs, job)
+		worker.mu.Unlock()
+
+		ocfworker.RespondJSON(w, http.StatusCreated, job)
+	})
+	mux.HandleFunc("GET /api/v1/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		worker.mu.Lock()
+		defer worker.mu.Unlock()
+		for _, job := range worker.jobs {
+			if job.ID.String() == r.PathValue("id") {
+				ocfworker.RespondJSON(w, http.StatusOK, job)
+				return
+			}
+		}
+		ocfworker.RespondError(w, http.StatusNotFound, "job not found")
+	})
+	mux.HandleFunc("GET /api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		worker.mu.Lock()
+		defer worker.mu.Unlock()
+		list := &models.JobListResponse{}
+		for _, job := range worker.jobs {
+			if status := r.URL.Query().Get("status"); status == "" || string(job.Status) == status {
+				list.Jobs = append(list.Jobs, *job)
+			}
+		}
+		list.Count = len(list.Jobs)
+		list.TotalCount = len(list.Jobs)
+		ocfworker.RespondJSON(w, http.StatusOK, list)
+	})
+	mux.HandleFunc("GET /api/v1/storage/courses/{id}/archive", func(w http.ResponseWriter, r *http.Request) {
+		archive := zip.NewWriter(w)
+		file, err := archive.Create("index.html")
+		require.NoError(t, err)
+		io.WriteString(file, "<html>"+r.PathValue("id")+"</html>")
+		require.NoError(t, archive.Close())
+	})
+
+	worker.Server = httptest.NewServer(mux)
+	t.Cleanup(worker.Close)
+	return worker
+}
+
+// completedJobs retourne le nombre de jobs créés terminés avec succès
+func (w *fakeWorker) completedJobs() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	count := 0
+	for _, job := range w.jobs {
+		if job.Status == models.StatusCompleted {
+			count++
+		}
+	}
+	return count
+}
+
+// uploadedSources retourne les fichiers uploadés pour les jobs de chaque
+// fichier principal, triés
+func (w *fakeWorker) uploadedSources() map[string][]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sources := map[string][]string{}
+	for _, job := range w.jobs {
+		files := slices.Clone(w.sources[job.ID.String()])
+		slices.Sort(files)
+		sources[job.SourcePath] = files
+	}
+	return sources
+}
+
+// newTestGenerator crée un générateur relié au worker, sans cache, pour
+// des sources locales
+func newTestGenerator(t *testing.T, worker *fakeWorker, config *Config) *Generator {
+	config.APIBaseURL = worker.URL
+	config.NoCache = true
+	config.WaitInterval = 10 * time.Millisecond
+	if config.OutputDir == "" {
+		config.OutputDir = t.TempDir()
+	}
+	require.NoError(t, config.Validate())
+
+	gen := New(config)
+	gen.logger = log.New(io.Discard, "", 0)
+	return gen
+}
+
+// writeSources écrit les fichiers des sources dans un répertoire temporaire
+func writeSources(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	writeSourcesIn(t, dir, files)
+	return dir
+}
+
+// writeSourcesIn écrit des fichiers dans dir, en remplaçant les existants
+func writeSourcesIn(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+}
+
+func TestGenerate_ReusesPreviousJob(t *testing.T) {
+	worker := newFakeWorker(t)
+	sourceDir := writeSources(t, map[string]string{"slides.md": "# Hello"})
+	ctx, cancel := ocfworker.TestContext()
+	defer cancel()
+
+	first, err := newTestGenerator(t, worker, &Config{SourceDir: sourceDir}).Generate(ctx)
+	require.NoError(t, err)
+	assert.False(t, first.Reused)
+	assert.FileExists(t, first.IndexPath)
+
+	// Mêmes sources : les résultats du premier job sont réutilisés
+	second, err := newTestGenerator(t, worker, &Config{SourceDir: sourceDir}).Generate(ctx)
+	require.NoError(t, err)
+	assert.True(t, second.Reused)
+	assert.Equal(t, first.JobID, second.JobID)
+	assert.FileExists(t, second.IndexPath)
+	assert.Equal(t, 1, worker.completedJobs())
+	assert.EqualValues(t, 1, worker.uploads.Load())
+
+	// --force relance la génération malgré le job précédent
+	forced, err := newTestGenerator(t, worker, &Config{SourceDir: sourceDir, Force: true}).Generate(ctx)
+	require.NoError(t, err)
+	assert.False(t, forced.Reused)
+	assert.NotEqual(t, first.JobID, forced.JobID)
+	assert.Equal(t, 2, worker.completedJobs())
+	assert.EqualValues(t, 2, worker.uploads.Load())
+
+	// D'autres formats de résultats forment une autre génération
+	zipOnly, err := newTestGenerator(t, worker, &Config{SourceDir: sourceDir, Formats: []string{FormatZIP}}).Generate(ctx)
+	require.NoError(t, err)
+	assert.False(t, zipOnly.Reused)
+	assert.Equal(t, 3, worker.completedJobs())
+}
+
+func TestApplyManifest_RootAndSubfolder(t *testing.T) {
+	sourceDir := writeSources(t, map[string]string{
+		"ocf.yaml":        "theme: seriph\nformats: [zip]\noutput: ./dist\nmetadata: {team: root, course: go}\n",
+		"slides/ocf.yaml": "entry: talk.md\nformats: [tar]\nmetadata: {team: slides}\n",
+		"slides/talk.md":  "# Talk\n",
+		"slides/notes.md": "# Notes\n",
+		"other/intro.md":  "# Intro\n",
+	})
+	ctx, cancel := ocfworker.TestContext()
+	defer cancel()
+
+	apply := func(t *testing.T, config *Config) (*Config, *fetchedSources, error) {
+		t.Helper()
+		config.SourceDir = sourceDir
+		g := &Generator{config: config, logger: log.New(io.Discard, "", 0)}
+		sources, err := g.fetchSources(ctx)
+		require.NoError(t, err)
+		gen, err := g.applyManifest(sources)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gen.config, sources, nil
+	}
+
+	t.Run("subfolder manifest wins over the root one", func(t *testing.T) {
+		config, sources, err := apply(t, &Config{Subfolder: "slides"})
+		require.NoError(t, err)
+
+		assert.Equal(t, filepath.Join(sourceDir, "slides"), sources.dir)
+		assert.ElementsMatch(t, []string{
+			filepath.Join(sourceDir, "slides", "talk.md"),
+			filepath.Join(sourceDir, "slides", "notes.md"),
+		}, sources.files)
+
+		assert.Equal(t, "talk.md", config.Entry)
+		assert.Equal(t, []string{FormatTAR}, config.Formats)
+		assert.Equal(t, "./dist", config.OutputDir)
+		assert.Equal(t, []string{"@slidev/theme-seriph"}, config.NpmPackages)
+		assert.Equal(t, map[string]interface{}{"team": "slides", "course": "go"}, config.Metadata)
+	})
+
+	t.Run("root manifest without subfolder manifest", func(t *testing.T) {
+		config, sources, err := apply(t, &Config{Subfolder: "other"})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(sourceDir, "other"), sources.dir)
+		assert.Equal(t, []string{FormatZIP}, config.Formats)
+		assert.Equal(t, "./dist", config.OutputDir)
+		assert.Empty(t, config.Entry)
+	})
+
+	t.Run("configuration wins over both", func(t *testing.T) {
+		config, _, err := apply(t, &Config{Subfolder: "slides", Formats: []string{FormatHTML}, Metadata: map[string]interface{}{"team": "cli"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{FormatHTML}, config.Formats)
+		assert.Equal(t, "cli", config.Metadata["team"])
+	})
+
+	t.Run("missing subfolder", func(t *testing.T) {
+		_, _, err := apply(t, &Config{Subfolder: "missing"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sous-dossier missing introuvable")
+	})
+}
+
+func TestApplyManifest_RootSubfolder(t *testing.T) {
+	sourceDir := writeSources(t, map[string]string{
+		"ocf.yaml":                     "subfolder: course\nformats: [zip]\n",
+		"course/ocf.yaml":              "entry: slides.md\n",
+		"course/slides.md":             "# Slides\n",
+		"README.md":                    "# Repo\n",
+		"course/drafts/unfinished.md":  "# Draft\n",
+		"course/public/images/logo.md": "# Logo\n",
+	})
+	g := &Generator{config: &Config{SourceDir: sourceDir, Exclude: []string{"drafts/**"}}, logger: log.New(io.Discard, "", 0)}
+	ctx, cancel := ocfworker.TestContext()
+	defer cancel()
+
+	sources, err := g.fetchSources(ctx)
+	require.NoError(t, err)
+	gen, err := g.applyManifest(sources)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(sourceDir, "course"), sources.dir)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(sourceDir, "course", "slides.md"),
+		filepath.Join(sourceDir, "course", "public", "images", "logo.md"),
+	}, sources.files)
+	assert.Equal(t, "slides.md", gen.config.Entry)
+	assert.Equal(t, []string{FormatZIP}, gen.config.Formats)
+
+	// Un sous-dossier introuvable rend le manifeste invalide
+	writeSourcesIn(t, sourceDir, map[string]string{"ocf.yaml": "subfolder: missing\n"})
+	sources, err = g.fetchSources(ctx)
+	require.NoError(t, err)
+	_, err = g.applyManifest(sources)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "manifeste ocf.yaml invalide: sous-dossier missing introuvable")
+}
+
+// repoProvider simule un fournisseur de dépôt distant dont les fichiers
+// sont en mémoire, et retient les sous-dossiers récupérés
+type repoProvider struct {
+	files   map[string]string
+	fetched []string
+}
+
+func (p *repoProvider) Name() string          { return "fake" }
+func (p *repoProvider) Match(u *url.URL) bool { return true }
+
+func (p *repoProvider) Parse(u *url.URL) (*Source, error) {
+	segments := pathSegments(u)
+	return &Source{Repo: "org/course", CloneURL: u.String(), Path: strings.Join(segments[2:], "/")}, nil
+}
+
+func (p *repoProvider) Resolve(ctx context.Context, source *Source) error {
+	source.Commit = "0123456789abcdef0123456789abcdef01234567"
+	return nil
+}
+
+func (p *repoProvider) Fetch(ctx context.Context, source *Source, outputDir string) ([]string, error) {
+	p.fetched = append(p.fetched, source.Path)
+	var files []string
+	for name, content := range p.files {
+		relativePath, ok := relativeToSubPath(name, source.Path)
+		if !ok {
+			continue
+		}
+		file, err := writeSourceFile(outputDir, relativePath, strings.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// fileRepoProvider lit aussi un fichier isolé du dépôt
+type fileRepoProvider struct {
+	repoProvider
+}
+
+func (p *fileRepoProvider) ReadFile(ctx context.Context, source *Source, name string) ([]byte, error) {
+	content, ok := p.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+func TestApplyManifest_RemoteSubfolder(t *testing.T) {
+	files := map[string]string{
+		"ocf.yaml":        "theme: seriph\nformats: [zip]\n",
+		"slides/ocf.yaml": "entry: talk.md\n",
+		"slides/talk.md":  "# Talk\n",
+		"other/intro.md":  "# Intro\n",
+	}
+	ctx, cancel := ocfworker.TestContext()
+	defer cancel()
+
+	apply := func(t *testing.T, provider SourceProvider) (*Config, *fetchedSources) {
+		t.Helper()
+		g := &Generator{config: &Config{RepoURL: "https://git.example.com/org/course/slides"}, providers: []SourceProvider{provider}, logger: log.New(io.Discard, "", 0)}
+		sources, err := g.fetchSources(ctx)
+		require.NoError(t, err)
+		t.Cleanup(sources.cleanup)
+		gen, err := g.applyManifest(sources)
+		require.NoError(t, err)
+		return gen.config, sources
+	}
+
+	t.Run("only the subfolder is fetched", func(t *testing.T) {
+		provider := &fileRepoProvider{repoProvider{files: files}}
+		config, sources := apply(t, provider)
+
+		// Le manifeste de la racine est lu à part
+		assert.Equal(t, []string{"slides"}, provider.fetched)
+		assert.Equal(t, "slides", sources.subfolder)
+		assert.Len(t, sources.files, 1)
+		assert.Equal(t, "talk.md", config.Entry)
+		assert.Equal(t, []string{FormatZIP}, config.Formats)
+		assert.Equal(t, []string{"@slidev/theme-seriph"}, config.NpmPackages)
+	})
+
+	t.Run("without file reads the repository root is fetched", func(t *testing.T) {
+		provider := &repoProvider{files: files}
+		config, sources := apply(t, provider)
+
+		assert.Equal(t, []string{""}, provider.fetched)
+		assert.Len(t, sources.files, 1)
+		assert.Equal(t, "talk.md", config.Entry)
+		assert.Equal(t, []string{FormatZIP}, config.Formats)
+	})
+}
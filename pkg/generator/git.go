@@ -0,0 +1,283 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	"github.com/go-git/go-git/v6/storage/memory"
+)
+
+// GitProvider récupère les présentations de n'importe quel serveur git, en
+// HTTPS ou SSH, avec go-git. La référence se précise après un # :
+//
+//	https://git.example.com/courses/intro.git#v2.0
+//	ssh://git@git.example.com/courses/intro.git
+//	git@git.example.com:courses/intro.git#main
+type GitProvider struct{}
+
+// Name implémente SourceProvider
+func (p *GitProvider) Name() string {
+	return "git"
+}
+
+// Match implémente SourceProvider : toute URL de dépôt est acceptée
+func (p *GitProvider) Match(u *url.URL) bool {
+	return true
+}
+
+// Parse implémente SourceProvider
+func (p *GitProvider) Parse(u *url.URL) (*Source, error) {
+	switch u.Scheme {
+	case "https", "http", "ssh", "git":
+	default:
+		return nil, fmt.Errorf("protocole git non pris en charge: %s", u.Scheme)
+	}
+
+	cloneURL := *u
+	cloneURL.Fragment = ""
+
+	return &Source{
+		BaseURL:  baseURL(u),
+		Repo:     u.Host + "/" + trimGitSuffix(strings.Trim(u.Path, "/")),
+		CloneURL: cloneURL.String(),
+		Ref:      u.Fragment,
+	}, nil
+}
+
+// Resolve implémente SourceProvider
+func (p *GitProvider) Resolve(ctx context.Context, source *Source) error {
+	return resolveRemoteRef(ctx, source)
+}
+
+// Fetch implémente SourceProvider : seul le commit résolu est téléchargé,
+// sans historique (profondeur 1)
+func (p *GitProvider) Fetch(ctx context.Context, source *Source, outputDir string) ([]string, error) {
+	if source.Commit == "" {
+		if err := p.Resolve(ctx, source); err != nil {
+			return nil, err
+		}
+	}
+
+	repo, err := git.Init(memory.NewStorage())
+	if err != nil {
+		return nil, err
+	}
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{source.CloneURL},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := source.Auth.gitAuth(source.CloneURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Récupérer la référence par son nom si possible : tous les serveurs
+	// n'acceptent pas de servir un commit demandé par son SHA
+	src := source.refName.String()
+	if src == "" {
+		src = source.Commit
+	}
+	err = remote.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: []config.RefSpec{config.RefSpec("+" + src + ":refs/heads/source")},
+		Depth:    1,
+		Tags:     plumbing.NoTags,
+		Auth:     auth,
+	})
+	switch {
+	case errors.Is(err, git.ErrExactSHA1NotSupported):
+		return nil, fmt.Errorf("le serveur ne permet pas de récupérer le commit %s directement, utiliser une branche ou un tag", source.Commit)
+	case err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate):
+		return nil, fmt.Errorf("récupération de %s: %w", source, remoteError(source, err))
+	}
+
+	commit, err := repo.CommitObject(plumbing.NewHash(source.Commit))
+	if err != nil {
+		return nil, fmt.Errorf("lecture du commit %s: %w", source.Commit, err)
+	}
+
+	return extractCommit(commit, outputDir, source.Path)
+}
+
+// resolveRemoteRef résout la référence d'une source comme git ls-remote :
+// une seule requête au serveur, sans rien télécharger. Une référence vide
+// désigne la branche par défaut ; un SHA complet est accepté tel quel.
+func resolveRemoteRef(ctx context.Context, source *Source) error {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{source.CloneURL},
+	})
+
+	auth, err := source.Auth.gitAuth(source.CloneURL)
+	if err != nil {
+		return err
+	}
+
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return remoteError(source, err)
+	}
+
+	hashes := make(map[plumbing.ReferenceName]plumbing.Hash, len(refs))
+	var head *plumbing.Reference
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD {
+			head = ref
+			continue
+		}
+		hashes[ref.Name()] = ref.Hash()
+	}
+
+	// Un tag annoté pointe vers un objet tag : utiliser le commit pelé
+	commitOf := func(name plumbing.ReferenceName) (plumbing.Hash, bool) {
+		if peeled, ok := hashes[name+"^{}"]; ok {
+			return peeled, true
+		}
+		hash, ok := hashes[name]
+		return hash, ok
+	}
+
+	if source.Ref == "" {
+		switch {
+		case head == nil:
+			return fmt.Errorf("dépôt vide ou branche par défaut inconnue: %s", source.CloneURL)
+		case head.Type() == plumbing.SymbolicReference:
+			hash, ok := commitOf(head.Target())
+			if !ok {
+				return fmt.Errorf("branche par défaut introuvable: %s", head.Target().Short())
+			}
+			source.refName, source.Commit = head.Target(), hash.String()
+		default:
+			source.refName, source.Commit = plumbing.HEAD, head.Hash().String()
+		}
+		return nil
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName(source.Ref),
+		plumbing.NewTagReferenceName(source.Ref),
+		plumbing.ReferenceName(source.Ref),
+	} {
+		if hash, ok := commitOf(name); ok {
+			source.refName, source.Commit = name, hash.String()
+			return nil
+		}
+	}
+
+	if plumbing.IsHash(source.Ref) {
+		source.refName, source.Commit = "", strings.ToLower(source.Ref)
+
+		// Un commit en tête d'une branche ou d'un tag se récupère par son nom
+		for name, hash := range hashes {
+			if hash.String() == source.Commit && (name.IsBranch() || name.IsTag()) {
+				source.refName = plumbing.ReferenceName(strings.TrimSuffix(name.String(), "^{}"))
+				break
+			}
+		}
+		return nil
+	}
+	if isHexString(source.Ref) {
+		return fmt.Errorf("référence introuvable: %s (un commit doit être désigné par son SHA complet)", source.Ref)
+	}
+	return fmt.Errorf("référence introuvable: %s%s", source.Ref, availableRefs(hashes))
+}
+
+// availableRefs liste quelques branches et tags du dépôt pour les messages d'erreur
+func availableRefs(hashes map[plumbing.ReferenceName]plumbing.Hash) string {
+	const maxRefs = 10
+
+	var names []string
+	for name := range hashes {
+		if (name.IsBranch() || name.IsTag()) && !strings.HasSuffix(name.String(), "^{}") {
+			names = append(names, name.Short())
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	sort.Strings(names)
+	if len(names) > maxRefs {
+		names = append(names[:maxRefs], "...")
+	}
+	return " (disponibles: " + strings.Join(names, ", ") + ")"
+}
+
+// isHexString indique si s ressemble à un SHA de commit abrégé
+func isHexString(s string) bool {
+	if len(s) < 4 || len(s) > 40 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// remoteError traduit les erreurs de transport git les plus courantes
+func remoteError(source *Source, err error) error {
+	switch {
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return fmt.Errorf("dépôt introuvable: %s", source.CloneURL)
+	case errors.Is(err, transport.ErrEmptyRemoteRepository):
+		return fmt.Errorf("dépôt vide: %s", source.CloneURL)
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		return fmt.Errorf("accès refusé au dépôt %s (dépôt privé ? identifiants: %s): %w", source.CloneURL, source.Auth, err)
+	}
+	return err
+}
+
+// extractCommit écrit les fichiers Slidev du sous-dossier subPath d'un commit
+func extractCommit(commit *object.Commit, outputDir, subPath string) ([]string, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	if subPath != "" {
+		tree, err = tree.Tree(subPath)
+		if err != nil {
+			return nil, fmt.Errorf("sous-dossier introuvable dans le dépôt: %s", subPath)
+		}
+	}
+
+	var files []string
+	err = tree.Files().ForEach(func(file *object.File) error {
+		relativePath, ok := relativeToSubPath(file.Name, "")
+		if !ok || !file.Mode.IsFile() {
+			return nil
+		}
+
+		reader, err := file.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		outputPath, err := writeSourceFile(outputDir, relativePath, reader)
+		if err != nil {
+			return err
+		}
+		files = append(files, outputPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
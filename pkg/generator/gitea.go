@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GiteaProvider récupère les présentations hébergées sur Gitea ou Forgejo
+// (ex: Codeberg) via l'API d'archives des dépôts.
+//
+// URLs reconnues :
+//
+//	https://codeberg.org/owner/repo
+//	https://gitea.example.com/owner/repo/src/branch/<branche>/<chemin>
+//	https://gitea.example.com/owner/repo/src/tag/<tag>/<chemin>
+//	https://gitea.example.com/owner/repo/src/commit/<sha>/<chemin>
+type GiteaProvider struct {
+	// HTTPClient télécharge les archives (défaut : client sans timeout,
+	// la durée étant bornée par le contexte)
+	HTTPClient *http.Client
+}
+
+// Name implémente SourceProvider
+func (p *GiteaProvider) Name() string {
+	return "gitea"
+}
+
+// Match implémente SourceProvider
+func (p *GiteaProvider) Match(u *url.URL) bool {
+	if !isHTTPURL(u) {
+		return false
+	}
+	switch {
+	case u.Host == "codeberg.org", u.Host == "gitea.com":
+		return true
+	case strings.HasPrefix(u.Host, "gitea."), strings.HasPrefix(u.Host, "forgejo."):
+		return true
+	}
+	for _, kind := range []string{"/src/branch/", "/src/tag/", "/src/commit/"} {
+		if strings.Contains(u.Path, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse implémente SourceProvider
+func (p *GiteaProvider) Parse(u *url.URL) (*Source, error) {
+	segments := pathSegments(u)
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("URL Gitea invalide: %s (attendu: https://gitea.example.com/owner/repo)", u)
+	}
+
+	owner, repo := segments[0], trimGitSuffix(segments[1])
+	source := &Source{
+		BaseURL:  baseURL(u),
+		Repo:     owner + "/" + repo,
+		CloneURL: fmt.Sprintf("%s/%s/%s.git", baseURL(u), owner, repo),
+	}
+
+	// Gérer src/branch|tag|commit/<ref>/<chemin>
+	if len(segments) > 4 && segments[2] == "src" {
+		switch segments[3] {
+		case "branch", "tag", "commit":
+			source.Ref = segments[4]
+			source.Path = strings.Join(segments[5:], "/")
+		}
+	}
+
+	return source, nil
+}
+
+// Resolve implémente SourceProvider
+func (p *GiteaProvider) Resolve(ctx context.Context, source *Source) error {
+	return resolveRemoteRef(ctx, source)
+}
+
+// Fetch implémente SourceProvider
+func (p *GiteaProvider) Fetch(ctx context.Context, source *Source, outputDir string) ([]string, error) {
+	if source.Commit == "" {
+		if err := p.Resolve(ctx, source); err != nil {
+			return nil, err
+		}
+	}
+
+	archiveURL := fmt.Sprintf("%s/api/v1/repos/%s/archive/%s.zip", source.BaseURL, source.Repo, source.Commit)
+	header := http.Header{}
+	if source.Auth != nil && source.Auth.Token != "" {
+		header.Set("Authorization", "token "+source.Auth.Token)
+	}
+
+	return downloadArchive(ctx, httpClientOrDefault(p.HTTPClient), archiveURL, header, outputDir, source.Path)
+}
+
+// ReadFile implémente FileReader
+func (p *GiteaProvider) ReadFile(ctx context.Context, source *Source, name string) ([]byte, error) {
+	fileURL := fmt.Sprintf("%s/api/v1/repos/%s/raw/%s?ref=%s", source.BaseURL, source.Repo, name, source.Commit)
+	header := http.Header{}
+	if source.Auth != nil && source.Auth.Token != "" {
+		header.Set("Authorization", "token "+source.Auth.Token)
+	}
+
+	return downloadFile(ctx, httpClientOrDefault(p.HTTPClient), fileURL, header)
+}
@@ -1,214 +1,114 @@
 package generator
 
 import (
-	"archive/zip"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
 	"strings"
-	"time"
-
-	"github.com/go-git/go-git/v6"
-	"github.com/go-git/go-git/v6/storage/memory"
 )
 
-// GitHubDownloader gère le téléchargement des dépôts GitHub
-type GitHubDownloader struct {
-	httpClient *http.Client
+// GitHubProvider récupère les présentations hébergées sur GitHub (ou GitHub
+// Enterprise) via les archives ZIP des dépôts.
+//
+// URLs reconnues :
+//
+//	https://github.com/owner/repo
+//	https://github.com/owner/repo/tree/<ref>/<chemin>
+//	https://github.com/owner/repo/commit/<sha>
+type GitHubProvider struct {
+	// HTTPClient télécharge les archives (défaut : client sans timeout,
+	// la durée étant bornée par le contexte)
+	HTTPClient *http.Client
 }
 
-// NewGitHubDownloader crée un nouveau téléchargeur
-func NewGitHubDownloader() *GitHubDownloader {
-	return &GitHubDownloader{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+// Name implémente SourceProvider
+func (p *GitHubProvider) Name() string {
+	return "github"
 }
 
-// DownloadRepo télécharge et extrait un dépôt GitHub
-func (d *GitHubDownloader) DownloadRepo(ctx context.Context, repoURL, outputDir, subfolder string) ([]string, error) {
-	if deadline, ok := ctx.Deadline(); ok {
-		fmt.Printf("Context deadline: %v (time remaining: %v)\n", deadline, time.Until(deadline))
-	}
-
-	// Check your HTTP client timeout
-	fmt.Printf("HTTP client timeout: %v\n", d.httpClient.Timeout)
-
-	// Parser l'URL GitHub
-	owner, repo, branch, subPath := parseGitHubURL(repoURL)
-	if owner == "" || repo == "" {
-		return nil, fmt.Errorf("URL GitHub invalide: %s", repoURL)
-	}
-
-	// Ajouter le subfolder au subPath
-	if subfolder != "" {
-		if subPath != "" {
-			subPath = subPath + "/" + subfolder
-		} else {
-			subPath = subfolder
-		}
-	}
-
-	// Construire l'URL ZIP
-	zipURL := fmt.Sprintf("https://github.com/%s/%s/archive/%s.zip", owner, repo, branch)
-
-	// Télécharger
-	req, err := http.NewRequestWithContext(ctx, "GET", zipURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	originalTimeout := d.httpClient.Timeout
-
-	// Remove timeout for this request
-	d.httpClient.Timeout = 0 // 0 means no timeout
-
-	// Restore it after
-	defer func() {
-		d.httpClient.Timeout = originalTimeout
-	}()
-
-	resp, err := d.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erreur HTTP %d", resp.StatusCode)
-	}
-
-	// Fichier temporaire
-	tempFile, err := os.CreateTemp("", "repo-*.zip")
-	if err != nil {
-		return nil, err
-	}
-	defer os.Remove(tempFile.Name())
-	defer tempFile.Close()
-
-	// Copier le contenu
-	progressReader := &ProgressReader{Reader: resp.Body, lastLog: time.Now()}
-	if _, err := io.Copy(tempFile, progressReader); err != nil {
-		fmt.Printf("Copy failed after downloading %d bytes: %v\n", progressReader.bytesRead, err)
-		return nil, err
-	}
-
-	// Extraire
-	branchSplit := strings.Split(branch, "/")
-	branchShortName := branchSplit[len(branchSplit)-1]
-	return d.extractRepo(tempFile.Name(), outputDir, fmt.Sprintf("%s-%s", repo, branchShortName), subPath)
+// Match implémente SourceProvider
+func (p *GitHubProvider) Match(u *url.URL) bool {
+	return isHTTPURL(u) && (u.Host == "github.com" || u.Host == "www.github.com")
 }
 
-func parseGitHubURL(url string) (owner, repo, branch, subPath string) {
-	// Supprimer le préfixe
-	trimmedUrl := strings.TrimPrefix(url, "https://github.com/")
-	parts := strings.Split(trimmedUrl, "/")
-
-	if len(parts) < 2 {
-		return
+// Parse implémente SourceProvider
+func (p *GitHubProvider) Parse(u *url.URL) (*Source, error) {
+	segments := pathSegments(u)
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("URL GitHub invalide: %s (attendu: https://github.com/owner/repo)", u)
 	}
 
-	owner = parts[0]
-	repo = parts[1]
-
-	gitRepo, errGitOpen := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
-		URL:      url,
-		Progress: os.Stdout,
-	})
-	if errGitOpen != nil {
-		fmt.Printf("Failed opening git repo %s bytes: %v\n", url, errGitOpen)
+	owner, repo := segments[0], trimGitSuffix(segments[1])
+	source := &Source{
+		BaseURL:  baseURL(u),
+		Repo:     owner + "/" + repo,
+		CloneURL: fmt.Sprintf("%s/%s/%s.git", baseURL(u), owner, repo),
 	}
 
-	head, _ := gitRepo.Head()
-
-	branch = head.Name().String()
-
-	// Gérer tree/branch/path
-	if len(parts) > 2 && parts[2] == "tree" {
-		if len(parts) > 3 {
-			branch = parts[3]
-		}
-		if len(parts) > 4 {
-			subPath = strings.Join(parts[4:], "/")
+	// Gérer tree/<ref>/<chemin>, blob/<ref>/<chemin> et commit/<sha>
+	if len(segments) > 3 {
+		switch segments[2] {
+		case "tree", "blob", "commit":
+			source.Ref = segments[3]
+			source.Path = strings.Join(segments[4:], "/")
 		}
 	}
 
-	return
+	return source, nil
 }
 
-func (d *GitHubDownloader) extractRepo(zipFile, outputDir, repoPrefix, subPath string) ([]string, error) {
-	reader, err := zip.OpenReader(zipFile)
-	if err != nil {
-		return nil, err
-	}
-	defer reader.Close()
-
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return nil, err
-	}
-
-	var extractedFiles []string
-
-	for _, file := range reader.File {
-		// Supprimer le préfixe du dépôt
-		relativePath := strings.TrimPrefix(file.Name, repoPrefix+"/")
-		if relativePath == file.Name {
-			continue
-		}
-
-		// Filtrer par subPath
-		if subPath != "" {
-			if !strings.HasPrefix(relativePath, subPath) {
-				continue
-			}
-			relativePath = strings.TrimPrefix(relativePath, subPath)
-			relativePath = strings.TrimPrefix(relativePath, "/")
-		}
-
-		if relativePath == "" || file.FileInfo().IsDir() {
-			continue
-		}
-
-		// Vérifier si c'est un fichier supporté
-		if !isSlidevFile(relativePath) {
-			continue
-		}
-
-		outputPath := filepath.Join(outputDir, relativePath)
-
-		// Créer les dossiers parents
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-			return nil, err
-		}
+// Resolve implémente SourceProvider
+func (p *GitHubProvider) Resolve(ctx context.Context, source *Source) error {
+	return resolveRemoteRef(ctx, source)
+}
 
-		// Extraire le fichier
-		if err := extractZipEntry(file, outputPath); err != nil {
+// Fetch implémente SourceProvider
+func (p *GitHubProvider) Fetch(ctx context.Context, source *Source, outputDir string) ([]string, error) {
+	if source.Commit == "" {
+		if err := p.Resolve(ctx, source); err != nil {
 			return nil, err
 		}
+	}
 
-		extractedFiles = append(extractedFiles, outputPath)
+	// Les archives publiques ne sont pas authentifiées : un dépôt privé passe
+	// par l'API
+	archiveURL := fmt.Sprintf("%s/%s/archive/%s.zip", source.BaseURL, source.Repo, source.Commit)
+	header := http.Header{}
+	if source.Auth != nil && source.Auth.Token != "" {
+		archiveURL = fmt.Sprintf("%s/repos/%s/zipball/%s", githubAPIURL(source), source.Repo, source.Commit)
+		header.Set("Authorization", "Bearer "+source.Auth.Token)
 	}
 
-	return extractedFiles, nil
+	return downloadArchive(ctx, httpClientOrDefault(p.HTTPClient), archiveURL, header, outputDir, source.Path)
 }
 
-func extractZipEntry(file *zip.File, outputPath string) error {
-	reader, err := file.Open()
-	if err != nil {
-		return err
+// ReadFile implémente FileReader
+func (p *GitHubProvider) ReadFile(ctx context.Context, source *Source, name string) ([]byte, error) {
+	fileURL := fmt.Sprintf("%s/%s/raw/%s/%s", source.BaseURL, source.Repo, source.Commit, name)
+	header := http.Header{}
+	if source.Auth != nil && source.Auth.Token != "" {
+		fileURL = fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s", githubAPIURL(source), source.Repo, name, source.Commit)
+		header.Set("Authorization", "Bearer "+source.Auth.Token)
+		header.Set("Accept", "application/vnd.github.raw+json")
 	}
-	defer reader.Close()
 
-	writer, err := os.Create(outputPath)
-	if err != nil {
-		return err
+	return downloadFile(ctx, httpClientOrDefault(p.HTTPClient), fileURL, header)
+}
+
+// githubAPIURL retourne la racine de l'API d'un serveur GitHub :
+// api.github.com, ou /api/v3 pour GitHub Enterprise
+func githubAPIURL(source *Source) string {
+	if strings.TrimPrefix(source.BaseURL, "https://") == "github.com" {
+		return "https://api.github.com"
 	}
-	defer writer.Close()
+	return source.BaseURL + "/api/v3"
+}
 
-	_, err = io.Copy(writer, reader)
-	return err
+// httpClientOrDefault retourne le client HTTP d'un fournisseur ou celui par défaut
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return downloadClient
 }
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitLabProvider récupère les présentations hébergées sur GitLab (gitlab.com
+// ou auto-hébergé) via l'API d'archives des dépôts.
+//
+// URLs reconnues :
+//
+//	https://gitlab.com/group/subgroup/project
+//	https://gitlab.example.com/group/project/-/tree/<ref>/<chemin>
+//
+// Une instance auto-hébergée dont l'hôte ne commence pas par "gitlab." n'est
+// reconnue que par ses URLs en /-/ ; sinon, forcer le fournisseur "gitlab".
+type GitLabProvider struct {
+	// HTTPClient télécharge les archives (défaut : client sans timeout,
+	// la durée étant bornée par le contexte)
+	HTTPClient *http.Client
+}
+
+// Name implémente SourceProvider
+func (p *GitLabProvider) Name() string {
+	return "gitlab"
+}
+
+// Match implémente SourceProvider
+func (p *GitLabProvider) Match(u *url.URL) bool {
+	if !isHTTPURL(u) {
+		return false
+	}
+	return u.Host == "gitlab.com" || strings.HasPrefix(u.Host, "gitlab.") || strings.Contains(u.Path, "/-/")
+}
+
+// Parse implémente SourceProvider
+func (p *GitLabProvider) Parse(u *url.URL) (*Source, error) {
+	segments := pathSegments(u)
+
+	// Le chemin du projet s'arrête au séparateur "-" des URLs GitLab
+	projectSegments := segments
+	var rest []string
+	for i, segment := range segments {
+		if segment == "-" {
+			projectSegments, rest = segments[:i], segments[i+1:]
+			break
+		}
+	}
+
+	if len(projectSegments) < 2 {
+		return nil, fmt.Errorf("URL GitLab invalide: %s (attendu: https://gitlab.example.com/group/project)", u)
+	}
+	projectSegments[len(projectSegments)-1] = trimGitSuffix(projectSegments[len(projectSegments)-1])
+	project := strings.Join(projectSegments, "/")
+
+	source := &Source{
+		BaseURL:  baseURL(u),
+		Repo:     project,
+		CloneURL: fmt.Sprintf("%s/%s.git", baseURL(u), project),
+	}
+
+	// Gérer -/tree/<ref>/<chemin>, -/blob/<ref>/<chemin> et -/commit/<sha>
+	if len(rest) > 1 {
+		switch rest[0] {
+		case "tree", "blob", "commit":
+			source.Ref = rest[1]
+			source.Path = strings.Join(rest[2:], "/")
+		}
+	}
+
+	return source, nil
+}
+
+// Resolve implémente SourceProvider
+func (p *GitLabProvider) Resolve(ctx context.Context, source *Source) error {
+	return resolveRemoteRef(ctx, source)
+}
+
+// Fetch implémente SourceProvider : l'API ne renvoie que le sous-dossier demandé
+func (p *GitLabProvider) Fetch(ctx context.Context, source *Source, outputDir string) ([]string, error) {
+	if source.Commit == "" {
+		if err := p.Resolve(ctx, source); err != nil {
+			return nil, err
+		}
+	}
+
+	query := url.Values{}
+	query.Set("sha", source.Commit)
+	if source.Path != "" {
+		query.Set("path", source.Path)
+	}
+
+	archiveURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/archive.zip?%s",
+		source.BaseURL, url.PathEscape(source.Repo), query.Encode())
+
+	header := http.Header{}
+	if source.Auth != nil && source.Auth.Token != "" {
+		header.Set("PRIVATE-TOKEN", source.Auth.Token)
+	}
+
+	return downloadArchive(ctx, httpClientOrDefault(p.HTTPClient), archiveURL, header, outputDir, source.Path)
+}
+
+// ReadFile implémente FileReader
+func (p *GitLabProvider) ReadFile(ctx context.Context, source *Source, name string) ([]byte, error) {
+	fileURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/files/%s/raw?ref=%s",
+		source.BaseURL, url.PathEscape(source.Repo), url.PathEscape(name), source.Commit)
+
+	header := http.Header{}
+	if source.Auth != nil && source.Auth.Token != "" {
+		header.Set("PRIVATE-TOKEN", source.Auth.Token)
+	}
+
+	return downloadFile(ctx, httpClientOrDefault(p.HTTPClient), fileURL, header)
+}
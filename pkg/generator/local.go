@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoredDirs ne sont jamais uploadés depuis un répertoire local
+var ignoredDirs = map[string]bool{
+	"node_modules": true,
+	"dist":         true,
+}
+
+// collectLocalFiles liste les fichiers Slidev d'un répertoire local, en
+// ignorant les dépendances, les builds et les dossiers cachés (.git...)
+func collectLocalFiles(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s n'est pas un répertoire", root)
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != root && (ignoredDirs[entry.Name()] || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.Type().IsRegular() && isSlidevFile(entry.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
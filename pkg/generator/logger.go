@@ -3,6 +3,8 @@ package generator
 import (
 	"log"
 	"os"
+
+	ocfworker "ocf-worker-sdk"
 )
 
 // verboseLogger implémente un logger verbeux pour le SDK
@@ -17,17 +19,17 @@ func NewVerboseLogger() *verboseLogger {
 }
 
 func (l *verboseLogger) Debug(msg string, fields ...interface{}) {
-	l.logger.Printf("[DEBUG] %s %v", msg, fields)
+	l.logger.Printf("[DEBUG] %s %s", msg, ocfworker.FormatFields(fields...))
 }
 
 func (l *verboseLogger) Info(msg string, fields ...interface{}) {
-	l.logger.Printf("[INFO] %s %v", msg, fields)
+	l.logger.Printf("[INFO] %s %s", msg, ocfworker.FormatFields(fields...))
 }
 
 func (l *verboseLogger) Warn(msg string, fields ...interface{}) {
-	l.logger.Printf("[WARN] %s %v", msg, fields)
+	l.logger.Printf("[WARN] %s %s", msg, ocfworker.FormatFields(fields...))
 }
 
 func (l *verboseLogger) Error(msg string, fields ...interface{}) {
-	l.logger.Printf("[ERROR] %s %v", msg, fields)
+	l.logger.Printf("[ERROR] %s %s", msg, ocfworker.FormatFields(fields...))
 }
@@ -0,0 +1,385 @@
+package generator
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestNames sont les noms du manifeste, cherché à la racine des sources
+// et dans le sous-dossier de la présentation (voir Generator.applyManifest)
+var manifestNames = []string{"ocf.yaml", "ocf.yml"}
+
+// Formats de résultats d'une génération
+const (
+	FormatHTML = "html"
+	FormatZIP  = "zip"
+	FormatTAR  = "tar"
+)
+
+// DefaultFormats sont les résultats téléchargés par défaut : le site extrait
+// et son archive ZIP
+var DefaultFormats = []string{FormatHTML, FormatZIP}
+
+// Manifest est le contenu d'un manifeste ocf.yaml, qui décrit la génération
+// d'un dépôt. Les options de la ligne de commande restent prioritaires, puis
+// le manifeste du sous-dossier sur celui de la racine.
+type Manifest struct {
+	// File est le chemin du manifeste lu, relatif à la racine des sources
+	File string `yaml:"-"`
+
+	Entry     string   `yaml:"entry"`
+	Decks     []string `yaml:"decks"`
+	Subfolder string   `yaml:"subfolder"`
+	// Theme est un thème Slidev, installé comme package npm (voir themePackage)
+	Theme    string   `yaml:"theme"`
+	Packages []string `yaml:"packages"`
+	// Include et Exclude filtrent les fichiers uploadés (motifs relatifs au
+	// sous-dossier de la présentation, ** pour plusieurs dossiers)
+	Include     []string               `yaml:"include"`
+	Exclude     []string               `yaml:"exclude"`
+	Formats     []string               `yaml:"formats"`
+	Output      string                 `yaml:"output"`
+	Concurrency int                    `yaml:"concurrency"`
+	WaitTimeout string                 `yaml:"wait_timeout"`
+	Metadata    map[string]interface{} `yaml:"metadata"`
+
+	waitTimeout time.Duration
+}
+
+// manifestSchemaJSON est le schéma JSON du manifeste
+//
+//go:embed manifest.schema.json
+var manifestSchemaJSON []byte
+
+// manifestSchema est le schéma décodé du manifeste
+var manifestSchema = mustParseSchema(manifestSchemaJSON)
+
+// loadManifest lit le manifeste à la racine de dir ; retourne nil s'il n'y
+// en a pas
+func loadManifest(dir string) (*Manifest, error) {
+	return findManifest(func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, name))
+	})
+}
+
+// findManifest lit le premier manifeste trouvé par readFile, qui retourne
+// une erreur os.ErrNotExist pour un fichier absent ; retourne nil s'il n'y en
+// a pas
+func findManifest(readFile func(name string) ([]byte, error)) (*Manifest, error) {
+	for _, name := range manifestNames {
+		data, err := readFile(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := parseManifest(data)
+		if err != nil {
+			return nil, fmt.Errorf("manifeste %s invalide: %w", name, err)
+		}
+		manifest.File = name
+		return manifest, nil
+	}
+	return nil, nil
+}
+
+// parseManifest valide un manifeste selon son schéma puis le décode
+func parseManifest(data []byte) (*Manifest, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return &Manifest{}, nil
+	}
+	if err := manifestSchema.validate("", document); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+
+	if manifest.Entry != "" && len(manifest.Decks) > 0 {
+		return nil, fmt.Errorf("entry et decks ne s'utilisent pas ensemble")
+	}
+	if manifest.WaitTimeout != "" {
+		timeout, err := time.ParseDuration(manifest.WaitTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("wait_timeout: durée invalide %q (ex: 20m)", manifest.WaitTimeout)
+		}
+		manifest.waitTimeout = timeout
+	}
+	for _, pattern := range slices.Concat(manifest.Include, manifest.Exclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("motif invalide %q: %w", pattern, err)
+		}
+	}
+	return manifest, nil
+}
+
+// apply retourne une copie de la configuration complétée par le manifeste :
+// chaque réglage du manifeste ne s'applique que si la configuration ne le
+// précise pas déjà, sauf les packages npm qui s'ajoutent à ceux demandés
+func (m *Manifest) apply(config *Config) *Config {
+	merged := *config
+
+	if merged.Entry == "" && !merged.IsMultiDeck() {
+		merged.Entry = m.Entry
+		merged.Decks = m.Decks
+	}
+
+	packages := slices.Clone(config.NpmPackages)
+	for _, pkg := range append([]string{themePackage(m.Theme)}, m.Packages...) {
+		if pkg != "" && !slices.Contains(packages, pkg) {
+			packages = append(packages, pkg)
+		}
+	}
+	merged.NpmPackages = packages
+
+	if len(merged.Include) == 0 {
+		merged.Include = m.Include
+	}
+	if len(merged.Exclude) == 0 {
+		merged.Exclude = m.Exclude
+	}
+	if len(merged.Formats) == 0 {
+		merged.Formats = m.Formats
+	}
+	if merged.OutputDir == "" {
+		merged.OutputDir = m.Output
+	}
+	if merged.Concurrency == 0 {
+		merged.Concurrency = m.Concurrency
+	}
+	if merged.WaitTimeout == 0 {
+		merged.WaitTimeout = m.waitTimeout
+	}
+
+	if len(m.Metadata) > 0 {
+		metadata := make(map[string]interface{}, len(m.Metadata)+len(config.Metadata))
+		for key, value := range m.Metadata {
+			metadata[key] = value
+		}
+		for key, value := range config.Metadata {
+			metadata[key] = value
+		}
+		merged.Metadata = metadata
+	}
+
+	return &merged
+}
+
+// filterFiles garde les fichiers (chemins sous baseDir) retenus par les
+// motifs include et exclude, hors manifeste
+func filterFiles(baseDir string, files, include, exclude []string) []string {
+	filtered := make([]string, 0, len(files))
+	for _, file := range files {
+		rel, err := filepath.Rel(baseDir, file)
+		if err != nil {
+			continue
+		}
+		name := filepath.ToSlash(rel)
+
+		if slices.Contains(manifestNames, name) {
+			continue
+		}
+		if len(include) > 0 && !matchAny(include, name) {
+			continue
+		}
+		if matchAny(exclude, name) {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+	return filtered
+}
+
+// matchAny indique si name, ou l'un de ses dossiers parents, correspond à
+// l'un des motifs
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		for prefix := name; prefix != "."; prefix = path.Dir(prefix) {
+			if matchGlob(pattern, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob compare un chemin à un motif de path.Match, où un segment **
+// correspond à un nombre quelconque de dossiers
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// jsonSchema est le sous-ensemble de JSON Schema utilisé par le manifeste
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []string               `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	MinLength            *int                   `json:"minLength"`
+	MinItems             *int                   `json:"minItems"`
+}
+
+func mustParseSchema(data []byte) *jsonSchema {
+	schema := &jsonSchema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		panic(fmt.Sprintf("schéma du manifeste invalide: %v", err))
+	}
+	return schema
+}
+
+// validate vérifie une valeur décodée du YAML ; les erreurs indiquent le
+// chemin de la valeur fautive (ex: decks[1])
+func (s *jsonSchema) validate(at string, value interface{}) error {
+	fail := func(format string, args ...interface{}) error {
+		if at == "" {
+			return fmt.Errorf(format, args...)
+		}
+		return fmt.Errorf("%s: %s", at, fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("objet attendu, %s trouvé", yamlTypeName(value))
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fail("clé inconnue %q", key)
+				}
+				continue
+			}
+			if err := property.validate(joinSchemaPath(at, key), object[key]); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fail("liste attendue, %s trouvé", yamlTypeName(value))
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			return fail("au moins %d élément(s) attendu(s)", *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fail("texte attendu, %s trouvé", yamlTypeName(value))
+		}
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			return fail("valeur vide")
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			return fail("valeur %q invalide (valeurs possibles: %s)", str, strings.Join(s.Enum, ", "))
+		}
+
+	case "integer":
+		var number float64
+		switch n := value.(type) {
+		case int:
+			number = float64(n)
+		case float64:
+			if n != math.Trunc(n) {
+				return fail("entier attendu, %v trouvé", n)
+			}
+			number = n
+		default:
+			return fail("entier attendu, %s trouvé", yamlTypeName(value))
+		}
+		if s.Minimum != nil && number < *s.Minimum {
+			return fail("valeur %v inférieure au minimum %v", number, *s.Minimum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("booléen attendu, %s trouvé", yamlTypeName(value))
+		}
+	}
+	return nil
+}
+
+func joinSchemaPath(at, key string) string {
+	if at == "" {
+		return key
+	}
+	return at + "." + key
+}
+
+// yamlTypeName décrit le type d'une valeur décodée du YAML
+func yamlTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "objet"
+	case []interface{}:
+		return "liste"
+	case string:
+		return "texte"
+	case int, float64:
+		return "nombre"
+	case bool:
+		return "booléen"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,207 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	manifest, err := parseManifest([]byte(`
+entry: talk.md
+theme: seriph
+packages: ["@slidev/addon-qrcode"]
+include: ["slides/**"]
+exclude: ["drafts/**"]
+formats: [html, tar]
+output: ./dist
+concurrency: 3
+wait_timeout: 20m
+metadata:
+  team: formation
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "talk.md", manifest.Entry)
+	assert.Equal(t, []string{FormatHTML, FormatTAR}, manifest.Formats)
+	assert.Equal(t, 3, manifest.Concurrency)
+	assert.Equal(t, 20*time.Minute, manifest.waitTimeout)
+	assert.Equal(t, map[string]interface{}{"team": "formation"}, manifest.Metadata)
+
+	empty, err := parseManifest([]byte("# rien\n"))
+	require.NoError(t, err)
+	assert.Equal(t, &Manifest{}, empty)
+}
+
+func TestParseManifest_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{name: "wrong type", manifest: "entry: 3", err: "entry: texte attendu, nombre trouvé"},
+		{name: "wrong item type", manifest: "decks: [a.md, 2]", err: "decks[1]: texte attendu, nombre trouvé"},
+		{name: "list instead of string", manifest: "output: [a, b]", err: "output: texte attendu, liste trouvé"},
+		{name: "below minimum", manifest: "concurrency: 0", err: "concurrency: valeur 0 inférieure au minimum 1"},
+		{name: "not an integer", manifest: "concurrency: 1.5", err: "concurrency: entier attendu, 1.5 trouvé"},
+		{name: "empty string", manifest: `entry: ""`, err: "entry: valeur vide"},
+		{name: "empty list", manifest: "decks: []", err: "decks: au moins 1 élément(s) attendu(s)"},
+		{name: "enum", manifest: "formats: [pdf]", err: `formats[0]: valeur "pdf" invalide (valeurs possibles: html, zip, tar)`},
+		{name: "unknown key", manifest: "entry: a.md\ncolour: red", err: `clé inconnue "colour"`},
+		{name: "non-object root", manifest: "- a.md\n- b.md", err: "objet attendu, liste trouvé"},
+		{name: "scalar root", manifest: "slides.md", err: "objet attendu, texte trouvé"},
+		{name: "non-object metadata", manifest: "metadata: [a]", err: "metadata: objet attendu, liste trouvé"},
+		{name: "entry and decks", manifest: "entry: a.md\ndecks: [b.md]", err: "entry et decks ne s'utilisent pas ensemble"},
+		{name: "invalid duration", manifest: "wait_timeout: soon", err: `wait_timeout: durée invalide "soon"`},
+		{name: "negative duration", manifest: "wait_timeout: -5m", err: `wait_timeout: durée invalide "-5m"`},
+		{name: "invalid pattern", manifest: `exclude: ["[drafts"]`, err: `motif invalide "[drafts"`},
+		{name: "invalid YAML", manifest: "entry: [a", err: "yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseManifest([]byte(tt.manifest))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	manifest, err := loadManifest(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, manifest)
+
+	dir := writeSources(t, map[string]string{"ocf.yml": "entry: talk.md\n"})
+	manifest, err = loadManifest(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "ocf.yml", manifest.File)
+	assert.Equal(t, "talk.md", manifest.Entry)
+
+	dir = writeSources(t, map[string]string{"ocf.yaml": "concurrency: -1\n"})
+	_, err = loadManifest(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "manifeste ocf.yaml invalide: concurrency")
+}
+
+func TestMatchAny(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "slides/**", name: "slides/intro.md", want: true},
+		{pattern: "slides/**", name: "slides/images/logo.png", want: true},
+		{pattern: "slides/**", name: "other/slides/intro.md", want: false},
+		{pattern: "slides/**", name: "slides.md", want: false},
+		{pattern: "**/*.md", name: "slides.md", want: true},
+		{pattern: "**/*.md", name: "chapter1/slides.md", want: true},
+		{pattern: "**/*.md", name: "part1/chapter1/slides.md", want: true},
+		{pattern: "**/*.md", name: "public/logo.svg", want: false},
+		{pattern: "public", name: "public/logo.svg", want: true},
+		{pattern: "public", name: "public/images/logo.svg", want: true},
+		{pattern: "public", name: "publications/a.md", want: false},
+		{pattern: "./public/", name: "public/logo.svg", want: true},
+		{pattern: "*.md", name: "slides.md", want: true},
+		{pattern: "*.md", name: "sub/a.md", want: false},
+		{pattern: "drafts/*.md", name: "drafts/old.md", want: true},
+		{pattern: "drafts/*.md", name: "drafts/2024/old.md", want: false},
+		{pattern: "**/drafts/**", name: "chapter1/drafts/old.md", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchAny([]string{tt.pattern}, tt.name))
+		})
+	}
+}
+
+func TestFilterFiles(t *testing.T) {
+	baseDir := filepath.FromSlash("/src")
+	files := []string{
+		filepath.FromSlash("/src/ocf.yaml"),
+		filepath.FromSlash("/src/slides.md"),
+		filepath.FromSlash("/src/public/logo.svg"),
+		filepath.FromSlash("/src/drafts/old.md"),
+		filepath.FromSlash("/src/sub/ocf.yaml"),
+	}
+
+	assert.Equal(t, []string{
+		filepath.FromSlash("/src/slides.md"),
+		filepath.FromSlash("/src/public/logo.svg"),
+		filepath.FromSlash("/src/drafts/old.md"),
+		filepath.FromSlash("/src/sub/ocf.yaml"),
+	}, filterFiles(baseDir, files, nil, nil), "seul le manifeste de la racine est retiré")
+
+	assert.Equal(t, []string{
+		filepath.FromSlash("/src/slides.md"),
+		filepath.FromSlash("/src/public/logo.svg"),
+	}, filterFiles(baseDir, files, []string{"*.md", "public"}, nil))
+
+	assert.Equal(t, []string{
+		filepath.FromSlash("/src/slides.md"),
+	}, filterFiles(baseDir, files, []string{"**/*.md"}, []string{"drafts/**"}))
+}
+
+func TestManifestApply(t *testing.T) {
+	manifest, err := parseManifest([]byte("output: ./dist\nconcurrency: 3\nwait_timeout: 20m\nformats: [tar]\ninclude: [\"*.md\"]\n"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		config Config
+		want   Config
+	}{
+		{
+			// Options non précisées sur la ligne de commande : le manifeste s'applique
+			name:   "manifest values",
+			config: Config{},
+			want:   Config{OutputDir: "./dist", Concurrency: 3, WaitTimeout: 20 * time.Minute, Formats: []string{FormatTAR}, Include: []string{"*.md"}},
+		},
+		{
+			name:   "explicit output",
+			config: Config{OutputDir: "./output"},
+			want:   Config{OutputDir: "./output", Concurrency: 3, WaitTimeout: 20 * time.Minute, Formats: []string{FormatTAR}, Include: []string{"*.md"}},
+		},
+		{
+			name:   "explicit concurrency and wait timeout",
+			config: Config{Concurrency: 1, WaitTimeout: 5 * time.Minute},
+			want:   Config{OutputDir: "./dist", Concurrency: 1, WaitTimeout: 5 * time.Minute, Formats: []string{FormatTAR}, Include: []string{"*.md"}},
+		},
+		{
+			name:   "explicit formats and include",
+			config: Config{Formats: []string{FormatHTML}, Include: []string{"slides/**"}},
+			want:   Config{OutputDir: "./dist", Concurrency: 3, WaitTimeout: 20 * time.Minute, Formats: []string{FormatHTML}, Include: []string{"slides/**"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := manifest.apply(&tt.config)
+			merged.NpmPackages = nil
+			assert.Equal(t, &tt.want, merged)
+		})
+	}
+}
+
+func TestManifestApply_EntryAndPackages(t *testing.T) {
+	manifest, err := parseManifest([]byte("decks: [a/slides.md, b/slides.md]\ntheme: seriph\npackages: [slidev-addon-qrcode, \"@slidev/theme-seriph\"]\nmetadata: {team: manifest, level: 1}\n"))
+	require.NoError(t, err)
+
+	merged := manifest.apply(&Config{NpmPackages: []string{"slidev-addon-qrcode"}, Metadata: map[string]interface{}{"team": "cli"}})
+	assert.Equal(t, []string{"a/slides.md", "b/slides.md"}, merged.Decks)
+	assert.Equal(t, []string{"slidev-addon-qrcode", "@slidev/theme-seriph"}, merged.NpmPackages)
+	assert.Equal(t, map[string]interface{}{"team": "cli", "level": 1}, merged.Metadata)
+
+	merged = manifest.apply(&Config{NpmPackages: []string{"@slidev/theme-seriph"}})
+	assert.Equal(t, []string{"@slidev/theme-seriph", "slidev-addon-qrcode"}, merged.NpmPackages)
+
+	// Un fichier principal ou des decks de la configuration remplacent ceux du manifeste
+	merged = manifest.apply(&Config{Entry: "talk.md"})
+	assert.Equal(t, "talk.md", merged.Entry)
+	assert.Empty(t, merged.Decks)
+	merged = manifest.apply(&Config{AllDecks: true})
+	assert.Empty(t, merged.Decks)
+}
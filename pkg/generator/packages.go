@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// workerBundledPackages sont installés dans l'image du worker (Slidev et
+// Playwright pour l'export) : inutile de les installer pour chaque job
+var workerBundledPackages = []string{
+	"@slidev/cli", "@slidev/client", "@slidev/parser", "@slidev/types",
+	"playwright-chromium",
+}
+
+// officialThemes sont les thèmes publiés sous @slidev, désignés par leur
+// nom court dans le frontmatter
+var officialThemes = []string{"default", "seriph", "apple-basic", "shibainu", "bricks"}
+
+// themePackage retourne le package npm d'un thème Slidev, selon la résolution
+// de Slidev : seriph devient @slidev/theme-seriph, un thème communautaire x
+// slidev-theme-x, un nom de package (@org/..., slidev-theme-x) est gardé tel
+// quel. Retourne "" pour l'absence de thème ou un thème local (./theme).
+func themePackage(theme string) string {
+	theme = strings.TrimSpace(theme)
+	switch {
+	case theme == "", theme == "none":
+		return ""
+	case strings.HasPrefix(theme, ".") || strings.HasPrefix(theme, "/"):
+		return ""
+	case strings.HasPrefix(theme, "@") || strings.HasPrefix(theme, "slidev-theme-"):
+		return theme
+	case slices.Contains(officialThemes, theme):
+		return "@slidev/theme-" + theme
+	}
+	return "slidev-theme-" + theme
+}
+
+// addonPackage retourne le package npm d'un addon Slidev : qrcode devient
+// slidev-addon-qrcode ; "" pour un addon local (./addon)
+func addonPackage(addon string) string {
+	addon = strings.TrimSpace(addon)
+	switch {
+	case addon == "":
+		return ""
+	case strings.HasPrefix(addon, ".") || strings.HasPrefix(addon, "/"):
+		return ""
+	case strings.HasPrefix(addon, "@") || strings.HasPrefix(addon, "slidev-addon-"):
+		return addon
+	}
+	return "slidev-addon-" + addon
+}
+
+// packageName retourne le nom d'un package sans sa version
+// (ex: @slidev/theme-seriph@^0.25 devient @slidev/theme-seriph)
+func packageName(spec string) string {
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i]
+	}
+	return spec
+}
+
+// deckPackages retourne les packages npm à installer pour le fichier
+// principal entry : ceux de la configuration, puis les dépendances des
+// package.json (racine des sources et dossier du fichier principal) et le
+// thème et les addons de son frontmatter. Les packages inférés que le worker
+// fournit déjà sont écartés.
+func (g *Generator) deckPackages(baseDir, entry string) []string {
+	var inferred []string
+
+	dirs := []string{"."}
+	if dir := path.Dir(entry); dir != "." {
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range dirs {
+		packageFile := filepath.Join(baseDir, filepath.FromSlash(dir), "package.json")
+		deps, err := packageDependencies(packageFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			g.logger.Printf("⚠️ Dépendances de %s ignorées: %v", path.Join(dir, "package.json"), err)
+		}
+		inferred = append(inferred, deps...)
+	}
+
+	if frontmatter, err := readFrontmatter(filepath.Join(baseDir, filepath.FromSlash(entry))); err == nil {
+		inferred = append(inferred, frontmatterPackages(frontmatter)...)
+	}
+
+	packages, bundled := mergePackages(g.config.NpmPackages, inferred)
+	for _, pkg := range bundled {
+		if slices.Contains(g.config.NpmPackages, pkg) {
+			g.logger.Printf("⚠️ %s est déjà fourni par le worker: son installation est probablement inutile", pkg)
+		} else {
+			g.logger.Printf("ℹ️ %s est déjà fourni par le worker: non installé", packageName(pkg))
+		}
+	}
+	if len(packages) > 0 {
+		g.logger.Printf("📦 Packages npm: %s", strings.Join(packages, ", "))
+	}
+	return packages
+}
+
+// mergePackages ajoute aux packages demandés les packages inférés qui n'y
+// sont pas déjà, hors ceux fournis par le worker. bundled liste les packages
+// fournis par le worker, demandés ou inférés.
+func mergePackages(requested, inferred []string) (packages, bundled []string) {
+	seen := map[string]bool{}
+	for _, pkg := range requested {
+		name := packageName(pkg)
+		if slices.Contains(workerBundledPackages, name) {
+			bundled = append(bundled, pkg)
+		}
+		if !seen[name] {
+			seen[name] = true
+			packages = append(packages, pkg)
+		}
+	}
+
+	for _, pkg := range inferred {
+		name := packageName(pkg)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if slices.Contains(workerBundledPackages, name) {
+			bundled = append(bundled, pkg)
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, bundled
+}
+
+// packageDependencies retourne les dependencies d'un package.json, avec leur
+// version (ex: @slidev/theme-seriph@^0.25.0). Les dépendances hors registre
+// npm (file:, git, workspace:...) sont laissées au npm install du worker.
+func packageDependencies(packageFile string) ([]string, error) {
+	data, err := os.ReadFile(packageFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("package.json invalide: %w", err)
+	}
+
+	deps := make([]string, 0, len(pkg.Dependencies))
+	for name, version := range pkg.Dependencies {
+		version = strings.TrimSpace(version)
+		switch {
+		case strings.ContainsAny(version, ":/"):
+			continue
+		case version == "", version == "*", version == "latest":
+			deps = append(deps, name)
+		default:
+			deps = append(deps, name+"@"+version)
+		}
+	}
+	sort.Strings(deps)
+	return deps, nil
+}
+
+// frontmatterPackages retourne les packages du thème et des addons déclarés
+// dans le frontmatter d'un fichier principal
+func frontmatterPackages(frontmatter map[string]interface{}) []string {
+	var packages []string
+	if theme, ok := frontmatter["theme"].(string); ok {
+		if pkg := themePackage(theme); pkg != "" {
+			packages = append(packages, pkg)
+		}
+	}
+
+	var addons []interface{}
+	switch value := frontmatter["addons"].(type) {
+	case []interface{}:
+		addons = value
+	case string:
+		addons = []interface{}{value}
+	}
+	for _, addon := range addons {
+		if name, ok := addon.(string); ok {
+			if pkg := addonPackage(name); pkg != "" {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	return packages
+}
@@ -0,0 +1,153 @@
+package generator
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageName(t *testing.T) {
+	assert.Equal(t, "@slidev/cli", packageName("@slidev/cli@^0.48"))
+	assert.Equal(t, "@slidev/cli", packageName("@slidev/cli"))
+	assert.Equal(t, "chart.js", packageName("chart.js@4.4.0"))
+	assert.Equal(t, "chart.js", packageName("chart.js"))
+}
+
+func TestThemePackage(t *testing.T) {
+	tests := []struct {
+		theme string
+		want  string
+	}{
+		{theme: "", want: ""},
+		{theme: "none", want: ""},
+		// Un thème local fait partie des sources : rien à installer
+		{theme: "./theme", want: ""},
+		{theme: "../shared/theme", want: ""},
+		{theme: "/opt/themes/custom", want: ""},
+		{theme: "seriph", want: "@slidev/theme-seriph"},
+		{theme: "default", want: "@slidev/theme-default"},
+		{theme: " apple-basic ", want: "@slidev/theme-apple-basic"},
+		// Un thème communautaire n'est pas publié sous @slidev
+		{theme: "penguin", want: "slidev-theme-penguin"},
+		{theme: "slidev-theme-penguin", want: "slidev-theme-penguin"},
+		{theme: "@org/slidev-theme-custom", want: "@org/slidev-theme-custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.theme, func(t *testing.T) {
+			assert.Equal(t, tt.want, themePackage(tt.theme))
+		})
+	}
+}
+
+func TestAddonPackage(t *testing.T) {
+	assert.Equal(t, "", addonPackage(""))
+	assert.Equal(t, "", addonPackage("./addon"))
+	assert.Equal(t, "", addonPackage("/opt/addons/custom"))
+	assert.Equal(t, "slidev-addon-qrcode", addonPackage("qrcode"))
+	assert.Equal(t, "slidev-addon-qrcode", addonPackage("slidev-addon-qrcode"))
+	assert.Equal(t, "@slidev/addon-qrcode", addonPackage("@slidev/addon-qrcode"))
+}
+
+func TestPackageDependencies(t *testing.T) {
+	dir := writeSources(t, map[string]string{
+		"package.json": `{
+			"dependencies": {
+				"@slidev/theme-seriph": "^0.25.0",
+				"chart.js": "*",
+				"slidev-addon-qrcode": "latest",
+				"lodash": "",
+				"local-theme": "file:./theme",
+				"forked": "github:owner/forked",
+				"shared": "workspace:*"
+			},
+			"devDependencies": {"typescript": "^5.0.0"}
+		}`,
+		"invalid/package.json": `{"dependencies": [}`,
+	})
+
+	deps, err := packageDependencies(filepath.Join(dir, "package.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@slidev/theme-seriph@^0.25.0", "chart.js", "lodash", "slidev-addon-qrcode"}, deps)
+
+	_, err = packageDependencies(filepath.Join(dir, "invalid", "package.json"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "package.json invalide")
+}
+
+func TestFrontmatterPackages(t *testing.T) {
+	tests := []struct {
+		name        string
+		frontmatter map[string]interface{}
+		want        []string
+	}{
+		{
+			name:        "theme and addons",
+			frontmatter: map[string]interface{}{"theme": "seriph", "addons": []interface{}{"qrcode", "./local", 42}},
+			want:        []string{"@slidev/theme-seriph", "slidev-addon-qrcode"},
+		},
+		{
+			name:        "single addon",
+			frontmatter: map[string]interface{}{"addons": "@org/addon-x"},
+			want:        []string{"@org/addon-x"},
+		},
+		{
+			name:        "local theme",
+			frontmatter: map[string]interface{}{"theme": "./theme"},
+			want:        nil,
+		},
+		{
+			name:        "nothing to install",
+			frontmatter: map[string]interface{}{"title": "Talk"},
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, frontmatterPackages(tt.frontmatter))
+		})
+	}
+}
+
+func TestMergePackages(t *testing.T) {
+	packages, bundled := mergePackages(
+		[]string{"chart.js@4.4.0", "@slidev/cli@^0.48", "chart.js"},
+		[]string{"chart.js@^3.0.0", "@slidev/theme-seriph", "playwright-chromium", "@slidev/cli", "@slidev/theme-seriph@^0.25.0"},
+	)
+
+	// La version demandée prime sur celle inférée
+	assert.Equal(t, []string{"chart.js@4.4.0", "@slidev/cli@^0.48", "@slidev/theme-seriph"}, packages)
+	assert.Equal(t, []string{"@slidev/cli@^0.48", "playwright-chromium"}, bundled)
+}
+
+func TestDeckPackages(t *testing.T) {
+	dir := writeSources(t, map[string]string{
+		"package.json":        `{"dependencies": {"@slidev/cli": "^0.48.0", "@slidev/theme-seriph": "^0.25.0", "shared": "file:../shared"}}`,
+		"talks/package.json":  `{"dependencies": {"slidev-addon-qrcode": "^1.0.0", "chart.js": "^4.0.0"}}`,
+		"talks/slides.md":     "---\ntheme: seriph\naddons: [qrcode, ./local-addon]\n---\n\n# Talk\n",
+		"local/slides.md":     "---\ntheme: ./theme\n---\n\n# Local\n",
+		"community/slides.md": "---\ntheme: penguin\n---\n\n# Community\n",
+	})
+	deckPackages := func(entry string, requested ...string) []string {
+		g := &Generator{config: &Config{NpmPackages: requested}, logger: log.New(io.Discard, "", 0)}
+		return g.deckPackages(dir, entry)
+	}
+
+	// Le thème et l'addon du frontmatter sont déjà dans les package.json,
+	// avec leur version ; --npm-package prime sur chart.js@^4.0.0
+	assert.Equal(t, []string{
+		"chart.js@4.4.0",
+		"@slidev/theme-seriph@^0.25.0",
+		"slidev-addon-qrcode@^1.0.0",
+	}, deckPackages("talks/slides.md", "chart.js@4.4.0"))
+
+	// Un thème local n'est pas installé depuis npm
+	assert.Equal(t, []string{"@slidev/theme-seriph@^0.25.0"}, deckPackages("local/slides.md"))
+
+	assert.Equal(t, []string{"@slidev/theme-seriph@^0.25.0", "slidev-theme-penguin"}, deckPackages("community/slides.md"))
+}
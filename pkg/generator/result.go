@@ -7,5 +7,39 @@ type Result struct {
 	OutputDir   string
 	IndexPath   string
 	ArchivePath string
-	Files       []string
+	// TarPath est l'archive TAR, si demandée (voir Config.Formats)
+	TarPath string
+	Files   []string
+	// Entry est le fichier principal de la présentation
+	Entry string
+	// Reused indique que les résultats d'un job précédent de mêmes sources
+	// ont été réutilisés, sans nouvelle génération
+	Reused bool
+
+	// Decks contient le résultat de chaque présentation d'une génération
+	// multi-decks ; IndexPath pointe alors sur la page qui les regroupe
+	Decks []*DeckResult
+}
+
+// MainPath retourne le résultat principal : la page index.html, ou à défaut
+// l'archive téléchargée
+func (r *Result) MainPath() string {
+	switch {
+	case r.IndexPath != "":
+		return r.IndexPath
+	case r.ArchivePath != "":
+		return r.ArchivePath
+	}
+	return r.TarPath
+}
+
+// DeckResult est le résultat d'une présentation d'une génération multi-decks
+type DeckResult struct {
+	// Name est le nom du deck, qui est aussi son sous-dossier de sortie
+	Name  string
+	Entry string
+	Title string
+	// Result est nil si la génération du deck a échoué (voir Err)
+	Result *Result
+	Err    error
 }
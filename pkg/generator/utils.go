@@ -128,3 +128,20 @@ func (pr *ProgressReader) Read(p []byte) (int, error) {
 
 	return n, err
 }
+
+func extractZipEntry(file *zip.File, outputPath string) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	_, err = io.Copy(writer, reader)
+	return err
+}
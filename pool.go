@@ -0,0 +1,435 @@
+package ocfworker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultEndpointHealthInterval is how often endpoint health is refreshed.
+	defaultEndpointHealthInterval = 15 * time.Second
+	// endpointHealthTimeout bounds a health refresh of all endpoints.
+	endpointHealthTimeout = 5 * time.Second
+	// maxEndpointPins bounds the number of job and course pins kept in memory.
+	maxEndpointPins = 10000
+)
+
+// Endpoint describes one OCF Worker instance of a multi-endpoint client.
+type Endpoint struct {
+	// URL is the base URL of the instance (e.g. "http://worker-1:8081")
+	URL string
+
+	// Weight is the relative share of new jobs sent to the instance. Default: 1.
+	Weight int
+}
+
+// EndpointStatus reports the state of an endpoint as seen by the client.
+type EndpointStatus struct {
+	URL       string
+	Weight    int
+	Healthy   bool
+	QueueSize int
+}
+
+// NewMultiClient creates a client spreading jobs over several OCF Worker instances.
+//
+// Each new job is assigned to a healthy instance, chosen by weight and queue
+// size (as reported by Worker.Health). All follow-up calls for that job and its
+// course — source uploads, Get, logs, results, archives, workspaces — are
+// pinned to the instance owning it. The first call mentioning a job ID
+// decides the instance, so uploading sources before Jobs.Create works as expected.
+//
+// Calls that don't relate to a job (health checks, listings, stats) go to any
+// healthy instance. When an instance fails at the connection level or answers
+// with server errors, it is considered unhealthy and new submissions fail over
+// to the remaining instances until the next health refresh. Health is
+// refreshed in the background, starting when the client is created; requests
+// never wait for it and are routed with the last known state.
+//
+// Example:
+//
+//	client := ocfworker.NewMultiClient([]ocfworker.Endpoint{
+//		{URL: "http://worker-1:8081", Weight: 2},
+//		{URL: "http://worker-2:8081"},
+//	}, ocfworker.WithTimeout(60*time.Second))
+func NewMultiClient(endpoints []Endpoint, opts ...Option) *Client {
+	pool := &endpointPool{
+		healthInterval: defaultEndpointHealthInterval,
+		pins:           newLRUCache[*endpointState](maxEndpointPins),
+	}
+
+	for _, endpoint := range endpoints {
+		weight := endpoint.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		base := strings.TrimRight(endpoint.URL, "/")
+		state := &endpointState{url: base, origin: base, weight: weight, healthy: true}
+		if u, err := url.Parse(base); err == nil {
+			state.origin = u.Scheme + "://" + u.Host
+		}
+		pool.endpoints = append(pool.endpoints, state)
+	}
+
+	baseURL := ""
+	if len(pool.endpoints) > 0 {
+		baseURL = pool.endpoints[0].url
+	}
+
+	client := NewClient(baseURL, append([]Option{withEndpointPool(pool)}, opts...)...)
+	pool.client = client
+
+	// Endpoints are considered healthy until the first refresh completes
+	pool.refreshIfStale()
+
+	return client
+}
+
+// WithEndpointHealthInterval sets how often a multi-endpoint client refreshes
+// the health and queue size of its endpoints. Default: 15 seconds.
+// It has no effect on single-endpoint clients.
+func WithEndpointHealthInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		if c.pool != nil && interval > 0 {
+			c.pool.healthInterval = interval
+		}
+	}
+}
+
+func withEndpointPool(pool *endpointPool) Option {
+	return func(c *Client) {
+		c.pool = pool
+	}
+}
+
+// Endpoints returns the status of each endpoint of a multi-endpoint client,
+// or nil for a single-endpoint client.
+func (c *Client) Endpoints() []EndpointStatus {
+	if c.pool == nil {
+		return nil
+	}
+
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+
+	statuses := make([]EndpointStatus, 0, len(c.pool.endpoints))
+	for _, ep := range c.pool.endpoints {
+		statuses = append(statuses, EndpointStatus{
+			URL:       ep.url,
+			Weight:    ep.weight,
+			Healthy:   ep.healthy,
+			QueueSize: ep.queueSize,
+		})
+	}
+	return statuses
+}
+
+type endpointHintKey struct{}
+
+// withEndpoint forces requests made with ctx to a given endpoint origin.
+// It is used for health refreshes and circuit breaker probes.
+func withEndpoint(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, endpointHintKey{}, origin)
+}
+
+type jobRoutingKey struct{}
+
+type jobRouting struct {
+	jobID    string
+	courseID string
+}
+
+// withJobRouting tells the endpoint pool which job and course a request
+// belongs to, for requests whose path doesn't say it (job creation).
+func withJobRouting(ctx context.Context, jobID, courseID string) context.Context {
+	return context.WithValue(ctx, jobRoutingKey{}, jobRouting{jobID: jobID, courseID: courseID})
+}
+
+// routingKeys extracts the job and course IDs a request relates to.
+func routingKeys(ctx context.Context, path string) (jobID, courseID string) {
+	if routing, ok := ctx.Value(jobRoutingKey{}).(jobRouting); ok {
+		return routing.jobID, routing.courseID
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		switch segments[i] {
+		case "jobs":
+			jobID = segments[i+1]
+		case "workspaces":
+			if segments[i+1] != "cleanup" {
+				jobID = segments[i+1]
+			}
+		case "courses":
+			courseID = segments[i+1]
+		}
+	}
+	return jobID, courseID
+}
+
+// endpointState is the client-side view of one endpoint.
+type endpointState struct {
+	url       string
+	origin    string
+	weight    int
+	healthy   bool
+	queueSize int
+	current   int // smooth weighted round-robin counter
+}
+
+// endpointPool routes requests over the endpoints of a multi-endpoint client.
+type endpointPool struct {
+	client         *Client
+	endpoints      []*endpointState
+	healthInterval time.Duration
+
+	mu          sync.Mutex
+	pins        *lruCache[*endpointState]
+	lastRefresh time.Time
+	refreshing  bool
+}
+
+// send routes a request to an endpoint and executes it. Requests that are not
+// bound to an endpoint yet fail over to another one on connection failures.
+func (p *endpointPool) send(req *http.Request, requestID string) (*http.Response, error) {
+	ctx := req.Context()
+	rest := strings.TrimPrefix(req.URL.String(), p.client.baseURL)
+	jobID, courseID := routingKeys(ctx, req.URL.Path)
+	tried := make(map[*endpointState]bool)
+
+	var lastErr error
+	for {
+		ep, fresh := p.route(ctx, jobID, courseID, tried)
+		if ep == nil {
+			if lastErr == nil {
+				lastErr = &RequestError{Method: req.Method, Path: req.URL.Path, RequestID: requestID,
+					Err: errors.New("no OCF Worker endpoint available")}
+			}
+			return nil, lastErr
+		}
+
+		if err := p.client.checkFeature(req, ep.url); err != nil {
+			if !fresh {
+				return nil, err
+			}
+			// Another endpoint may provide the feature
+			tried[ep] = true
+			lastErr = err
+			p.unpin(jobID, courseID)
+			continue
+		}
+
+		target, err := url.Parse(ep.url + p.client.endpointAPIPath(ctx, ep.url, rest))
+		if err != nil {
+			return nil, &RequestError{Method: req.Method, Path: req.URL.Path, RequestID: requestID, Err: err}
+		}
+		req.URL = target
+		req.Host = ""
+
+		resp, err := p.client.sendResolved(req, requestID)
+
+		var openErr *CircuitOpenError
+		if errors.As(err, &openErr) || isCircuitFailure(resp, err) {
+			p.markUnhealthy(ep)
+		}
+		if err == nil || !fresh || errors.Is(err, context.Canceled) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		// The request was not bound to this endpoint yet: try another one
+		tried[ep] = true
+		lastErr = err
+		p.unpin(jobID, courseID)
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		p.client.logger.Warn("Endpoint failed, trying another one",
+			LogKeyPath, req.URL.Path, LogKeyRequestID, requestID, "endpoint", ep.url, LogKeyError, err)
+	}
+}
+
+// route chooses the endpoint of a request. fresh is false when the request
+// is bound to its endpoint (pinned job or course, or explicit endpoint).
+func (p *endpointPool) route(ctx context.Context, jobID, courseID string, exclude map[*endpointState]bool) (ep *endpointState, fresh bool) {
+	if origin, ok := ctx.Value(endpointHintKey{}).(string); ok {
+		for _, ep := range p.endpoints {
+			if ep.origin == origin || ep.url == origin {
+				return ep, false
+			}
+		}
+	}
+
+	p.refreshIfStale()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if jobID != "" {
+		if ep, ok := p.pins.get("job:" + jobID); ok {
+			if courseID != "" {
+				p.pins.set("course:"+courseID, ep)
+			}
+			return ep, false
+		}
+	}
+	if courseID != "" {
+		if ep, ok := p.pins.get("course:" + courseID); ok {
+			if jobID != "" {
+				p.pins.set("job:"+jobID, ep)
+			}
+			return ep, false
+		}
+	}
+
+	ep = p.pick(exclude)
+	if ep != nil {
+		if jobID != "" {
+			p.pins.set("job:"+jobID, ep)
+		}
+		if courseID != "" {
+			p.pins.set("course:"+courseID, ep)
+		}
+	}
+	return ep, true
+}
+
+// pick selects an endpoint with smooth weighted round-robin. The weight of
+// each endpoint is lowered by its queue size. Unhealthy endpoints are only
+// used when no healthy one is left. Must be called with mu held.
+func (p *endpointPool) pick(exclude map[*endpointState]bool) *endpointState {
+	var candidates []*endpointState
+	for _, ep := range p.endpoints {
+		if ep.healthy && !exclude[ep] {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		for _, ep := range p.endpoints {
+			if !exclude[ep] {
+				candidates = append(candidates, ep)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var best *endpointState
+	total := 0
+	for _, ep := range candidates {
+		effective := ep.weight * 100 / (1 + ep.queueSize)
+		if effective < 1 {
+			effective = 1
+		}
+		ep.current += effective
+		total += effective
+		if best == nil || ep.current > best.current {
+			best = ep
+		}
+	}
+	best.current -= total
+
+	return best
+}
+
+func (p *endpointPool) unpin(jobID, courseID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if jobID != "" {
+		p.pins.remove("job:" + jobID)
+	}
+	if courseID != "" {
+		p.pins.remove("course:" + courseID)
+	}
+}
+
+func (p *endpointPool) markUnhealthy(ep *endpointState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ep.healthy {
+		ep.healthy = false
+		p.client.logger.Warn("Endpoint marked unhealthy", "endpoint", ep.url)
+	}
+}
+
+// refreshIfStale starts a background refresh of the health of all endpoints
+// when the last refresh is older than the health interval. Only one refresh
+// runs at a time; requests keep using the current state meanwhile.
+func (p *endpointPool) refreshIfStale() {
+	p.mu.Lock()
+	if p.refreshing || (!p.lastRefresh.IsZero() && time.Since(p.lastRefresh) < p.healthInterval) {
+		p.mu.Unlock()
+		return
+	}
+	p.refreshing = true
+	p.mu.Unlock()
+
+	// The refresh outlives the request that triggered it, not the client, and
+	// isn't attributed to that request. It is not a call of its own: Close
+	// doesn't wait for it but cancels it.
+	detached, stop := p.client.detach()
+	go func() {
+		defer stop()
+		p.refresh(detached)
+	}()
+}
+
+// refresh checks the health and queue size of all endpoints concurrently.
+func (p *endpointPool) refresh(ctx context.Context) {
+	refreshCtx, cancel := context.WithTimeout(ctx, endpointHealthTimeout)
+	defer cancel()
+
+	type result struct {
+		healthy   bool
+		queueSize int
+	}
+	results := make([]result, len(p.endpoints))
+
+	var wg sync.WaitGroup
+	for i, ep := range p.endpoints {
+		wg.Add(1)
+		go func(i int, ep *endpointState) {
+			defer wg.Done()
+			results[i].healthy, results[i].queueSize = p.checkEndpoint(withEndpoint(refreshCtx, ep.origin))
+		}(i, ep)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, ep := range p.endpoints {
+		ep.healthy = results[i].healthy
+		ep.queueSize = results[i].queueSize
+	}
+	p.lastRefresh = time.Now()
+	p.refreshing = false
+}
+
+// checkEndpoint queries the worker pool health of an endpoint, falling back
+// to the general health check.
+func (p *endpointPool) checkEndpoint(ctx context.Context) (healthy bool, queueSize int) {
+	workerHealth, err := p.client.Worker.Health(ctx)
+	if err == nil && workerHealth != nil {
+		return workerHealth.Status != "unhealthy", workerHealth.WorkerPool.QueueSize
+	}
+
+	health, err := p.client.Health.Check(ctx)
+	if err != nil {
+		return false, 0
+	}
+	return health.Status != "unhealthy", 0
+}
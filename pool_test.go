@@ -0,0 +1,236 @@
+package ocfworker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorker is a minimal worker instance accepting any job.
+type fakeWorker struct {
+	*httptest.Server
+
+	queueSize int
+
+	mu   sync.Mutex
+	jobs map[string]int // requests per job ID
+}
+
+func newFakeWorker(t *testing.T, queueSize int) *fakeWorker {
+	w := &fakeWorker{queueSize: queueSize, jobs: make(map[string]int)}
+
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+		switch {
+		case path == "/worker/health":
+			RespondJSON(rw, http.StatusOK, &models.WorkerHealthResponse{
+				Status:     "healthy",
+				WorkerPool: models.WorkerPoolHealth{QueueSize: w.queueSize},
+			})
+		case path == "/generate":
+			var req models.GenerationRequest
+			ReadJSONBody(t, r, &req)
+			w.count(req.JobID.String())
+			RespondJSON(rw, http.StatusCreated, NewJobResponse().WithID(req.JobID).Build())
+		case strings.HasPrefix(path, "/storage/jobs/"):
+			w.count(strings.Split(path, "/")[3])
+			RespondJSON(rw, http.StatusCreated, &models.FileUploadResponse{Count: 1})
+		case strings.HasPrefix(path, "/jobs/"):
+			w.count(strings.TrimPrefix(path, "/jobs/"))
+			RespondJSON(rw, http.StatusOK, NewJobResponse().Build())
+		default:
+			RespondError(rw, http.StatusNotFound, "route not found")
+		}
+	}))
+	t.Cleanup(w.Close)
+
+	return w
+}
+
+func (w *fakeWorker) count(jobID string) {
+	w.mu.Lock()
+	w.jobs[jobID]++
+	w.mu.Unlock()
+}
+
+func (w *fakeWorker) requests(jobID string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.jobs[jobID]
+}
+
+func (w *fakeWorker) jobCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.jobs)
+}
+
+func TestMultiClient_PinsJobToEndpoint(t *testing.T) {
+	first := newFakeWorker(t, 0)
+	second := newFakeWorker(t, 0)
+
+	client := NewMultiClient([]Endpoint{{URL: first.URL}, {URL: second.URL}})
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		req := MockGenerationRequest()
+		jobID := req.JobID.String()
+
+		_, err := client.Storage.UploadSources(ctx, jobID, []FileUpload{MockFileUpload("slides.md", "# Hello")})
+		require.NoError(t, err)
+		_, err = client.Jobs.Create(ctx, req)
+		require.NoError(t, err)
+		_, err = client.Jobs.Get(ctx, jobID)
+		require.NoError(t, err)
+
+		// Upload, creation and status all hit the same instance
+		assert.ElementsMatch(t, []int{0, 3}, []int{first.requests(jobID), second.requests(jobID)})
+	}
+
+	// Equal weights spread the two jobs over both instances
+	assert.Equal(t, 1, first.jobCount())
+	assert.Equal(t, 1, second.jobCount())
+}
+
+func TestMultiClient_FailsOverNewJobs(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	up := newFakeWorker(t, 0)
+
+	client := NewMultiClient([]Endpoint{{URL: downURL}, {URL: up.URL}})
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		req := MockGenerationRequest()
+		_, err := client.Jobs.Create(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, 1, up.requests(req.JobID.String()))
+	}
+
+	statuses := client.Endpoints()
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Healthy)
+	assert.True(t, statuses[1].Healthy)
+}
+
+func TestMultiClient_WeightsByQueueSize(t *testing.T) {
+	busy := newFakeWorker(t, 9)
+	idle := newFakeWorker(t, 0)
+
+	client := NewMultiClient([]Endpoint{{URL: busy.URL}, {URL: idle.URL}})
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	// Queue sizes are known once the initial background refresh completes
+	require.Eventually(t, func() bool { return client.Endpoints()[0].QueueSize == 9 }, time.Second, 5*time.Millisecond)
+
+	for i := 0; i < 11; i++ {
+		_, err := client.Jobs.Create(ctx, MockGenerationRequest())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, busy.jobCount())
+	assert.Equal(t, 10, idle.jobCount())
+	assert.Equal(t, 9, client.Endpoints()[0].QueueSize)
+}
+
+func TestMultiClient_RefreshDoesNotBlockRequests(t *testing.T) {
+	// An endpoint whose health checks hang until the refresh times out, but
+	// which accepts jobs
+	slow := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/health") {
+			<-r.Context().Done()
+			return
+		}
+		var req models.GenerationRequest
+		ReadJSONBody(t, r, &req)
+		RespondJSON(rw, http.StatusCreated, NewJobResponse().WithID(req.JobID).Build())
+	}))
+	t.Cleanup(slow.Close)
+
+	client := NewMultiClient([]Endpoint{{URL: slow.URL}})
+	t.Cleanup(func() { client.Close(context.Background()) })
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Jobs.Create(ctx, MockGenerationRequest())
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestMultiClient_RefreshIsNotAttributedToRequests(t *testing.T) {
+	var mu sync.Mutex
+	var healthRequestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/health") {
+			mu.Lock()
+			healthRequestIDs = append(healthRequestIDs, r.Header.Get(RequestIDHeader))
+			mu.Unlock()
+			RespondJSON(rw, http.StatusOK, &models.WorkerHealthResponse{Status: "healthy"})
+			return
+		}
+		var req models.GenerationRequest
+		ReadJSONBody(t, r, &req)
+		RespondJSON(rw, http.StatusCreated, NewJobResponse().WithID(req.JobID).Build())
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewMultiClient([]Endpoint{{URL: server.URL}}, WithEndpointHealthInterval(time.Millisecond))
+	t.Cleanup(func() { client.Close(context.Background()) })
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	// Let the initial refresh complete, so that the request triggers one
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(healthRequestIDs) > 0
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := client.Jobs.Create(WithRequestID(ctx, "req-trigger"), MockGenerationRequest())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(healthRequestIDs) > 1
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotContains(t, healthRequestIDs, "req-trigger")
+}
+
+func TestRoutingKeys(t *testing.T) {
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	jobID, courseID := routingKeys(ctx, "/api/v1/storage/jobs/j1/sources/slides.md")
+	assert.Equal(t, "j1", jobID)
+	assert.Empty(t, courseID)
+
+	jobID, courseID = routingKeys(ctx, "/api/v1/storage/courses/c1/results")
+	assert.Empty(t, jobID)
+	assert.Equal(t, "c1", courseID)
+
+	jobID, _ = routingKeys(ctx, "/api/v1/worker/workspaces/cleanup")
+	assert.Empty(t, jobID)
+
+	jobID, courseID = routingKeys(withJobRouting(ctx, "j2", "c2"), "/api/v1/generate")
+	assert.Equal(t, "j2", jobID)
+	assert.Equal(t, "c2", courseID)
+}
@@ -0,0 +1,223 @@
+package ocfworker
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default histogram buckets, in seconds.
+var (
+	defaultRequestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	defaultJobWaitBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+)
+
+// PrometheusRecorder is a MetricsRecorder that keeps metrics in memory and
+// exposes them in the Prometheus text exposition format.
+//
+// It implements http.Handler and can be mounted directly on a metrics endpoint:
+//
+//	recorder := ocfworker.NewPrometheusRecorder("")
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithMetrics(recorder))
+//	http.Handle("/metrics", recorder)
+//
+// The following metrics are exposed (with the default "ocfworker" namespace):
+//
+//	ocfworker_requests_total{method,endpoint,status}
+//	ocfworker_request_duration_seconds{method,endpoint,status}
+//	ocfworker_retries_total{endpoint}
+//	ocfworker_upload_bytes_total{endpoint}
+//	ocfworker_download_bytes_total{endpoint}
+//	ocfworker_job_wait_duration_seconds{status}
+type PrometheusRecorder struct {
+	namespace string
+
+	mu            sync.Mutex
+	requests      map[string]*histogram
+	retries       map[string]float64
+	uploadBytes   map[string]float64
+	downloadBytes map[string]float64
+	jobWaits      map[string]*histogram
+}
+
+// NewPrometheusRecorder creates a PrometheusRecorder whose metric names are
+// prefixed with namespace. An empty namespace defaults to "ocfworker".
+func NewPrometheusRecorder(namespace string) *PrometheusRecorder {
+	if namespace == "" {
+		namespace = "ocfworker"
+	}
+
+	return &PrometheusRecorder{
+		namespace:     namespace,
+		requests:      make(map[string]*histogram),
+		retries:       make(map[string]float64),
+		uploadBytes:   make(map[string]float64),
+		downloadBytes: make(map[string]float64),
+		jobWaits:      make(map[string]*histogram),
+	}
+}
+
+// ObserveRequest implements MetricsRecorder.
+func (p *PrometheusRecorder) ObserveRequest(method, endpoint string, status int, duration time.Duration) {
+	statusLabel := "error"
+	if status > 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	key := labels("method", method, "endpoint", endpoint, "status", statusLabel)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.requests[key]
+	if !ok {
+		h = newHistogram(defaultRequestBuckets)
+		p.requests[key] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// IncRetry implements MetricsRecorder.
+func (p *PrometheusRecorder) IncRetry(endpoint string) {
+	p.add(p.retries, labels("endpoint", endpoint), 1)
+}
+
+// AddUploadBytes implements MetricsRecorder.
+func (p *PrometheusRecorder) AddUploadBytes(endpoint string, n int64) {
+	p.add(p.uploadBytes, labels("endpoint", endpoint), float64(n))
+}
+
+// AddDownloadBytes implements MetricsRecorder.
+func (p *PrometheusRecorder) AddDownloadBytes(endpoint string, n int64) {
+	p.add(p.downloadBytes, labels("endpoint", endpoint), float64(n))
+}
+
+// ObserveJobWait implements MetricsRecorder.
+func (p *PrometheusRecorder) ObserveJobWait(status string, duration time.Duration) {
+	key := labels("status", status)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.jobWaits[key]
+	if !ok {
+		h = newHistogram(defaultJobWaitBuckets)
+		p.jobWaits[key] = h
+	}
+	h.observe(duration.Seconds())
+}
+
+// ServeHTTP writes all metrics in the Prometheus text exposition format.
+func (p *PrometheusRecorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = p.WriteText(w)
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format to w.
+func (p *PrometheusRecorder) WriteText(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	requestCounts := make(map[string]float64, len(p.requests))
+	for key, h := range p.requests {
+		requestCounts[key] = float64(h.count)
+	}
+
+	p.writeCounter(&b, "requests_total", "Total number of requests sent to the OCF Worker API.", requestCounts)
+	p.writeHistogram(&b, "request_duration_seconds", "Duration of requests sent to the OCF Worker API.", p.requests)
+	p.writeCounter(&b, "retries_total", "Total number of retried requests.", p.retries)
+	p.writeCounter(&b, "upload_bytes_total", "Total number of bytes sent in request bodies.", p.uploadBytes)
+	p.writeCounter(&b, "download_bytes_total", "Total number of bytes read from response bodies.", p.downloadBytes)
+	p.writeHistogram(&b, "job_wait_duration_seconds", "Time spent waiting for jobs to finish, by final status.", p.jobWaits)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (p *PrometheusRecorder) add(m map[string]float64, key string, v float64) {
+	p.mu.Lock()
+	m[key] += v
+	p.mu.Unlock()
+}
+
+func (p *PrometheusRecorder) writeCounter(b *strings.Builder, name, help string, values map[string]float64) {
+	name = p.namespace + "_" + name
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{%s} %s\n", name, key, formatFloat(values[key]))
+	}
+}
+
+func (p *PrometheusRecorder) writeHistogram(b *strings.Builder, name, help string, values map[string]*histogram) {
+	name = p.namespace + "_" + name
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+
+	for _, key := range sortedKeys(values) {
+		h := values[key]
+		for i, bound := range h.bounds {
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, key, formatFloat(bound), h.buckets[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, key, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, key, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, key, h.count)
+	}
+}
+
+// histogram is a cumulative Prometheus-style histogram.
+type histogram struct {
+	bounds  []float64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds:  bounds,
+		buckets: make([]uint64, len(bounds)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// labelValueEscaper escapes label values as the text exposition format
+// specifies: only backslash, double quote and line feed are escaped, other
+// characters (including non-ASCII) are written as UTF-8.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats name/value pairs as a Prometheus label set (without braces).
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelValueEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,317 @@
+package ocfworker
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EndpointClass groups endpoints sharing the same rate limit and
+// concurrency budget.
+type EndpointClass int
+
+const (
+	// EndpointClassLight covers cheap endpoints: job creation and status,
+	// listings, health checks, logs...
+	EndpointClassLight EndpointClass = iota
+	// EndpointClassHeavy covers source uploads, file downloads and archives.
+	EndpointClassHeavy
+)
+
+// String returns the lowercase name of the class.
+func (c EndpointClass) String() string {
+	if c == EndpointClassHeavy {
+		return "heavy"
+	}
+	return "light"
+}
+
+const (
+	// maxThrottleRetries is the number of times a request answered with
+	// 429 Too Many Requests is retried.
+	maxThrottleRetries = 3
+	// defaultRetryAfter is the pause applied after a 429 without Retry-After.
+	defaultRetryAfter = time.Second
+	// maxRetryAfter caps the pause requested by the server.
+	maxRetryAfter = 5 * time.Minute
+)
+
+// rateLimitConfig holds the limits of an endpoint class. Zero values mean unset.
+type rateLimitConfig struct {
+	rps         float64
+	burst       int
+	maxInFlight int
+}
+
+// WithRateLimit limits the rate of requests sent to the worker, per endpoint
+// class: light and heavy endpoints each get their own budget of rps requests
+// per second with bursts of up to burst requests.
+//
+// Use WithClassRateLimit to give heavy endpoints a different budget.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL,
+//		ocfworker.WithRateLimit(20, 40),
+//		ocfworker.WithClassRateLimit(ocfworker.EndpointClassHeavy, 2, 4),
+//	)
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.defaultLimits.rps = rps
+		c.defaultLimits.burst = burst
+	}
+}
+
+// WithClassRateLimit sets the rate limit of a single endpoint class,
+// overriding WithRateLimit for that class.
+func WithClassRateLimit(class EndpointClass, rps float64, burst int) Option {
+	return func(c *Client) {
+		limits := c.classLimits[class]
+		limits.rps = rps
+		limits.burst = burst
+		c.setClassLimits(class, limits)
+	}
+}
+
+// WithMaxInFlight caps the number of concurrent requests per endpoint class.
+// A request holds its slot until its response body is closed, so streamed
+// downloads count as in flight while they are being read.
+//
+// Use WithClassMaxInFlight to give heavy endpoints a different cap.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL,
+//		ocfworker.WithMaxInFlight(16),
+//		ocfworker.WithClassMaxInFlight(ocfworker.EndpointClassHeavy, 2),
+//	)
+func WithMaxInFlight(n int) Option {
+	return func(c *Client) {
+		c.defaultLimits.maxInFlight = n
+	}
+}
+
+// WithClassMaxInFlight sets the concurrency cap of a single endpoint class,
+// overriding WithMaxInFlight for that class.
+func WithClassMaxInFlight(class EndpointClass, n int) Option {
+	return func(c *Client) {
+		limits := c.classLimits[class]
+		limits.maxInFlight = n
+		c.setClassLimits(class, limits)
+	}
+}
+
+func (c *Client) setClassLimits(class EndpointClass, limits rateLimitConfig) {
+	if c.classLimits == nil {
+		c.classLimits = make(map[EndpointClass]rateLimitConfig)
+	}
+	c.classLimits[class] = limits
+}
+
+// limitsFor resolves the limits of a class, falling back to the defaults
+// for each unset value.
+func (c *Client) limitsFor(class EndpointClass) rateLimitConfig {
+	limits := c.classLimits[class]
+	if limits.rps <= 0 {
+		limits.rps = c.defaultLimits.rps
+		limits.burst = c.defaultLimits.burst
+	}
+	if limits.maxInFlight <= 0 {
+		limits.maxInFlight = c.defaultLimits.maxInFlight
+	}
+	return limits
+}
+
+// classifyEndpoint returns the class of a request from its method and route template.
+func classifyEndpoint(method, endpoint string) EndpointClass {
+	switch {
+	case method == http.MethodPost && endpoint == "/storage/jobs/:id/sources",
+		endpoint == "/storage/jobs/:id/sources/:filename",
+		endpoint == "/storage/courses/:id/results/:filename",
+		endpoint == "/storage/courses/:id/archive":
+		return EndpointClassHeavy
+	default:
+		return EndpointClassLight
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return defaultRetryAfter
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	} else {
+		return defaultRetryAfter
+	}
+
+	switch {
+	case delay < 0:
+		return 0
+	case delay > maxRetryAfter:
+		return maxRetryAfter
+	default:
+		return delay
+	}
+}
+
+// classLimiter enforces the rate limit and concurrency cap of an endpoint class.
+// It always exists, even without configured limits, so that 429 responses
+// pause the class.
+type classLimiter struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+}
+
+func newClassLimiter(limits rateLimitConfig) *classLimiter {
+	limiter := &classLimiter{bucket: newTokenBucket(limits.rps, limits.burst)}
+	if limits.maxInFlight > 0 {
+		limiter.slots = make(chan struct{}, limits.maxInFlight)
+	}
+	return limiter
+}
+
+// acquire waits for a rate limit token and a concurrency slot.
+// The returned function releases the slot; it is safe to call more than once.
+func (l *classLimiter) acquire(ctx context.Context) (func(), error) {
+	if err := l.bucket.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if l.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.slots })
+	}, nil
+}
+
+func (l *classLimiter) throttle(retryAfter time.Duration) {
+	l.bucket.throttle(retryAfter)
+}
+
+func (l *classLimiter) recover() {
+	l.bucket.recover()
+}
+
+// tokenBucket is a token bucket rate limiter whose rate adapts to throttling:
+// a 429 pauses it and halves its rate, successful requests restore it gradually.
+type tokenBucket struct {
+	mu          sync.Mutex
+	maxRate     float64 // configured tokens per second, 0 for unlimited
+	rate        float64 // current tokens per second
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if rps > 0 && burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rps)))
+	}
+	return &tokenBucket{
+		maxRate: rps,
+		rate:    rps,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// wait blocks until a token is available, the bucket is not paused, or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		delay := b.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(b.pausedUntil) {
+		return b.pausedUntil.Sub(now)
+	}
+	if b.maxRate <= 0 {
+		return 0
+	}
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// throttle pauses the bucket for retryAfter and halves its rate.
+func (b *tokenBucket) throttle(retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	until := time.Now().Add(retryAfter)
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+
+	if b.maxRate > 0 {
+		b.rate = math.Max(b.rate/2, b.maxRate/16)
+		b.tokens = 0
+	}
+}
+
+// recover raises the rate back towards its configured value after a successful request.
+func (b *tokenBucket) recover() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate < b.maxRate {
+		b.rate = math.Min(b.maxRate, b.rate+b.maxRate/10)
+	}
+}
+
+// releasingReadCloser releases a concurrency slot when the body is closed.
+type releasingReadCloser struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releasingReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
@@ -0,0 +1,176 @@
+package ocfworker
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyEndpoint(t *testing.T) {
+	assert.Equal(t, EndpointClassHeavy, classifyEndpoint("POST", "/storage/jobs/:id/sources"))
+	assert.Equal(t, EndpointClassLight, classifyEndpoint("GET", "/storage/jobs/:id/sources"))
+	assert.Equal(t, EndpointClassHeavy, classifyEndpoint("GET", "/storage/courses/:id/archive"))
+	assert.Equal(t, EndpointClassHeavy, classifyEndpoint("GET", "/storage/courses/:id/results/:filename"))
+	assert.Equal(t, EndpointClassLight, classifyEndpoint("GET", "/jobs/:id"))
+	assert.Equal(t, "heavy", EndpointClassHeavy.String())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, defaultRetryAfter, parseRetryAfter(""))
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, defaultRetryAfter, parseRetryAfter("garbage"))
+	assert.Equal(t, maxRetryAfter, parseRetryAfter("86400"))
+
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	delay := parseRetryAfter(date)
+	assert.InDelta(t, 10*time.Second, delay, float64(2*time.Second))
+}
+
+func TestRateLimit(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, MockHealthResponse("healthy"))
+	})
+
+	client := server.TestClient(WithRateLimit(20, 1))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := client.Health.Check(ctx)
+		require.NoError(t, err)
+	}
+
+	// One request is allowed immediately, the next four wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+}
+
+func TestMaxInFlightPerClass(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+	courseID := uuid.New().String()
+
+	server.On("GET", "/api/v1/storage/courses/"+courseID+"/results/slides.pdf", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write([]byte("pdf"))
+	})
+	server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, MockHealthResponse("healthy"))
+	})
+
+	client := server.TestClient(WithMaxInFlight(8), WithClassMaxInFlight(EndpointClassHeavy, 1))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	// Hold the single heavy slot with an unclosed body
+	body, err := client.Storage.DownloadResult(ctx, courseID, "slides.pdf")
+	require.NoError(t, err)
+
+	// Light endpoints have their own budget
+	_, err = client.Health.Check(ctx)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var completed atomic.Int32
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reader, err := client.Storage.DownloadResult(ctx, courseID, "slides.pdf")
+			if assert.NoError(t, err) {
+				reader.Close()
+				completed.Add(1)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), completed.Load(), "heavy requests must wait for the held slot")
+
+	body.Close()
+	wg.Wait()
+
+	assert.Equal(t, int32(3), completed.Load())
+	assert.Equal(t, 1, maxInFlight)
+}
+
+func TestTooManyRequestsRetry(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var attempts atomic.Int32
+	server.On("POST", "/api/v1/generate", func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			RespondError(w, http.StatusTooManyRequests, "slow down")
+			return
+		}
+		var req models.GenerationRequest
+		ReadJSONBody(t, r, &req)
+		RespondJSON(w, http.StatusCreated, NewJobResponse().WithID(req.JobID).Build())
+	})
+
+	recorder := NewPrometheusRecorder("")
+	client := server.TestClient(WithMetrics(recorder))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	req := MockGenerationRequest()
+	start := time.Now()
+	job, err := client.Jobs.Create(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, req.JobID, job.ID)
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+	assert.Equal(t, float64(1), recorder.retries[labels("endpoint", "/generate")])
+}
+
+func TestTooManyRequestsGivesUp(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	var attempts atomic.Int32
+	server.On("GET", "/api/v1/jobs/throttled", func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "0")
+		RespondError(w, http.StatusTooManyRequests, "slow down")
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext(10 * time.Second)
+	defer cancel()
+
+	_, err := client.Jobs.Get(ctx, "throttled")
+
+	AssertAPIError(t, err, http.StatusTooManyRequests, "slow down")
+	assert.True(t, IsTemporaryError(err))
+	assert.Equal(t, int32(maxThrottleRetries+1), attempts.Load())
+}
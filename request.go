@@ -0,0 +1,162 @@
+package ocfworker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// Request describes a call to the OCF Worker API.
+//
+// It is used internally by every service and can be passed to Client.Do to
+// reach endpoints the SDK doesn't wrap yet, with the same authentication,
+// tracing, rate limiting, retries and error handling.
+type Request struct {
+	// Method is the HTTP method. Default: GET.
+	Method string
+
+	// Path is the endpoint path relative to the API version prefix
+	// (e.g. "/jobs" for /api/v1/jobs)
+	Path string
+
+	// Query holds optional query string parameters
+	Query url.Values
+
+	// Body is JSON-encoded unless it is an io.Reader, which is sent as is
+	Body interface{}
+
+	// ContentType is the content type of an io.Reader body
+	ContentType string
+
+	// ContentLength is the size of an io.Reader body, sent as the
+	// Content-Length header. 0 means unknown: the body is sent chunked.
+	ContentLength int64
+
+	// ExpectedStatus lists the status codes considered successful.
+	// Default: 200 OK.
+	ExpectedStatus []int
+
+	// notFound, if set, builds the error returned for 404 responses
+	notFound func(resp *http.Response) error
+}
+
+// Do executes a request and decodes its JSON response into out.
+// out may be nil to discard the response body.
+//
+// Responses with an unexpected status are returned as an *APIError.
+//
+// Example:
+//
+//	// Call an endpoint not wrapped by the SDK yet
+//	var themes []string
+//	err := client.Do(ctx, &ocfworker.Request{Path: "/themes"}, &themes)
+//
+//	// PATCH with a JSON body
+//	err = client.Do(ctx, &ocfworker.Request{
+//		Method:         http.MethodPatch,
+//		Path:           "/jobs/" + jobID,
+//		Body:           map[string]string{"priority": "high"},
+//		ExpectedStatus: []int{http.StatusOK, http.StatusNoContent},
+//	}, nil)
+func (c *Client) Do(ctx context.Context, req *Request, out interface{}) error {
+	resp, err := c.doChecked(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return c.decodeResponse(resp, out)
+}
+
+// do executes a request and decodes its JSON response into a new T.
+func do[T any](ctx context.Context, c *Client, req *Request) (*T, error) {
+	resp, err := c.doChecked(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out T
+	if err := c.decodeResponse(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// doChecked executes a request and returns its response with an open body
+// when the status is expected, or the parsed API error otherwise.
+// It is used directly for streamed downloads.
+func (c *Client) doChecked(ctx context.Context, req *Request) (*http.Response, error) {
+	resp, err := c.doRaw(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound && req.notFound != nil {
+		resp.Body.Close()
+		return nil, req.notFound(resp)
+	}
+
+	expected := req.ExpectedStatus
+	if len(expected) == 0 {
+		expected = []int{http.StatusOK}
+	}
+	if !slices.Contains(expected, resp.StatusCode) {
+		defer resp.Body.Close()
+		return nil, parseAPIError(resp)
+	}
+
+	return resp, nil
+}
+
+// doRaw builds and sends a request without looking at the response status.
+func (c *Client) doRaw(ctx context.Context, req *Request) (*http.Response, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	target := c.baseURL + c.apiPath(ctx, req.Path)
+	if len(req.Query) > 0 {
+		target += "?" + req.Query.Encode()
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	switch b := req.Body.(type) {
+	case nil:
+	case io.Reader:
+		body = b
+		contentType = req.ContentType
+	default:
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(b); err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body = &buf
+		contentType = "application/json"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if req.ContentLength > 0 {
+		httpReq.ContentLength = req.ContentLength
+	}
+
+	return c.send(httpReq)
+}
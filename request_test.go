@@ -0,0 +1,87 @@
+package ocfworker
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Do(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	t.Run("PATCH with JSON body and query", func(t *testing.T) {
+		server.On("PATCH", "/api/v1/jobs/job-1", func(w http.ResponseWriter, r *http.Request) {
+			AssertContentType(t, r, "application/json")
+			assert.Equal(t, "true", r.URL.Query().Get("notify"))
+			assert.NotEmpty(t, r.Header.Get(RequestIDHeader))
+
+			var body map[string]string
+			ReadJSONBody(t, r, &body)
+			assert.Equal(t, "high", body["priority"])
+
+			RespondJSON(w, http.StatusAccepted, map[string]string{"priority": "high"})
+		})
+
+		client := server.TestClient()
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		var out map[string]string
+		err := client.Do(ctx, &Request{
+			Method:         http.MethodPatch,
+			Path:           "/jobs/job-1",
+			Query:          url.Values{"notify": []string{"true"}},
+			Body:           map[string]string{"priority": "high"},
+			ExpectedStatus: []int{http.StatusOK, http.StatusAccepted},
+		}, &out)
+
+		require.NoError(t, err)
+		assert.Equal(t, "high", out["priority"])
+	})
+
+	t.Run("PUT with raw body", func(t *testing.T) {
+		server.On("PUT", "/api/v1/themes/custom", func(w http.ResponseWriter, r *http.Request) {
+			AssertContentType(t, r, "text/css")
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "h1 { color: red }", string(body))
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		client := server.TestClient()
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		var out map[string]string
+		err := client.Do(ctx, &Request{
+			Method:         http.MethodPut,
+			Path:           "/themes/custom",
+			Body:           strings.NewReader("h1 { color: red }"),
+			ContentType:    "text/css",
+			ExpectedStatus: []int{http.StatusNoContent},
+		}, &out)
+
+		require.NoError(t, err)
+		assert.Nil(t, out)
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		server.On("DELETE", "/api/v1/jobs/job-2", func(w http.ResponseWriter, r *http.Request) {
+			RespondError(w, http.StatusConflict, "job is running")
+		})
+
+		client := server.TestClient()
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		err := client.Do(ctx, &Request{Method: http.MethodDelete, Path: "/jobs/job-2"}, nil)
+
+		AssertAPIError(t, err, http.StatusConflict, "job is running")
+		assert.NotEmpty(t, RequestIDFromError(err))
+	})
+}
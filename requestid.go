@@ -0,0 +1,49 @@
+package ocfworker
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestIDHeader is the HTTP header carrying the request ID.
+// The client sends it with every request and reads it back from responses,
+// so that errors and log lines can be matched with the worker's own logs.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a context carrying the given request ID.
+// Requests made with this context reuse the ID instead of generating a new one,
+// which lets callers propagate their own correlation IDs to the worker.
+//
+// Example:
+//
+//	ctx := ocfworker.WithRequestID(ctx, incomingRequestID)
+//	job, err := client.Jobs.Create(ctx, req)
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID set with WithRequestID, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// responseRequestID returns the request ID of a response: the one echoed by
+// the server if any, otherwise the one the client sent.
+func responseRequestID(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	if requestID := resp.Header.Get(RequestIDHeader); requestID != "" {
+		return requestID
+	}
+	if resp.Request != nil {
+		return resp.Request.Header.Get(RequestIDHeader)
+	}
+	return ""
+}
@@ -0,0 +1,255 @@
+package ocfworker
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// defaultMaxLightResponseSize bounds the responses of light endpoints.
+	defaultMaxLightResponseSize = 32 << 20
+	// defaultMaxLogSize bounds the logs returned by GetLogs.
+	defaultMaxLogSize = 10 << 20
+	// maxErrorBodySize bounds the error bodies read by parseAPIError.
+	maxErrorBodySize = 64 << 10
+)
+
+// TruncatedLogsMarker prefixes the logs returned by GetLogs when they exceed
+// the configured maximum size and only their end was kept.
+const TruncatedLogsMarker = "[... earlier logs truncated ...]\n"
+
+// ErrResponseTooLarge is returned when a response body exceeds the maximum
+// size configured for its endpoint class.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ResponseDecoder wraps a compressed response body into a decompressing reader.
+type ResponseDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// IsTruncatedLogs tells whether logs returned by GetLogs were truncated.
+func IsTruncatedLogs(logs string) bool {
+	return strings.HasPrefix(logs, TruncatedLogsMarker)
+}
+
+// WithMaxResponseSize bounds the decompressed size of responses, for every
+// endpoint class. Reading beyond the limit fails with ErrResponseTooLarge.
+// A size of 0 or less removes the limit.
+//
+// By default, light endpoints are limited to 32 MiB and heavy ones (file
+// downloads, archives) are unlimited. Job logs have their own limit, see WithMaxLogSize.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL,
+//		ocfworker.WithMaxResponseSize(4<<20),
+//		ocfworker.WithClassMaxResponseSize(ocfworker.EndpointClassHeavy, 2<<30),
+//	)
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) {
+		c.responseLimits[EndpointClassLight] = n
+		c.responseLimits[EndpointClassHeavy] = n
+	}
+}
+
+// WithClassMaxResponseSize bounds the decompressed size of the responses of
+// one endpoint class, overriding WithMaxResponseSize for that class.
+func WithClassMaxResponseSize(class EndpointClass, n int64) Option {
+	return func(c *Client) {
+		c.responseLimits[class] = n
+	}
+}
+
+// WithMaxLogSize bounds the logs returned by GetLogs (default 10 MiB).
+// Longer logs are not an error: their end is kept and prefixed with
+// TruncatedLogsMarker. A size of 0 or less removes the limit.
+func WithMaxLogSize(n int64) Option {
+	return func(c *Client) {
+		c.maxLogSize = n
+	}
+}
+
+// WithResponseDecoder registers a decoder for a Content-Encoding and
+// advertises it in the Accept-Encoding header of every request.
+// gzip and zstd are supported out of the box; registering one of them
+// replaces the built-in decoder.
+//
+// Example:
+//
+//	// Brotli support with github.com/andybalholm/brotli
+//	client := ocfworker.NewClient(baseURL,
+//		ocfworker.WithResponseDecoder("br", func(r io.Reader) (io.ReadCloser, error) {
+//			return io.NopCloser(brotli.NewReader(r)), nil
+//		}),
+//	)
+func WithResponseDecoder(encoding string, decoder ResponseDecoder) Option {
+	return func(c *Client) {
+		c.decoders[strings.ToLower(encoding)] = decoder
+	}
+}
+
+// builtinEncodings are the encodings decoded out of the box, in the order
+// they are advertised.
+var builtinEncodings = []string{"gzip", "zstd"}
+
+func defaultDecoders() map[string]ResponseDecoder {
+	return map[string]ResponseDecoder{"gzip": gzipDecoder, "zstd": zstdDecoder}
+}
+
+func gzipDecoder(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func zstdDecoder(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
+
+// acceptEncoding returns the Accept-Encoding header value, preferred encodings first.
+func (c *Client) acceptEncoding() string {
+	encodings := make([]string, 0, len(c.decoders))
+	for encoding := range c.decoders {
+		encodings = append(encodings, encoding)
+	}
+	// Encodings other than the built-in ones are registered on purpose: prefer them
+	slices.SortFunc(encodings, func(a, b string) int {
+		ia, ib := slices.Index(builtinEncodings, a), slices.Index(builtinEncodings, b)
+		switch {
+		case ia >= 0 && ib >= 0:
+			return ia - ib
+		case ia >= 0:
+			return 1
+		case ib >= 0:
+			return -1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+	return strings.Join(encodings, ", ")
+}
+
+// responseLimit returns the maximum body size of an endpoint, 0 for unlimited.
+// Logs are bounded by GetLogs itself, which truncates instead of failing.
+func (c *Client) responseLimit(endpoint string, class EndpointClass) int64 {
+	if endpoint == "/storage/jobs/:id/logs" {
+		return 0
+	}
+	return c.responseLimits[class]
+}
+
+// wrapResponseBody decompresses the body according to its Content-Encoding
+// and enforces the size limit of its endpoint.
+func (c *Client) wrapResponseBody(resp *http.Response, endpoint string, class EndpointClass) {
+	if encoding := strings.ToLower(resp.Header.Get("Content-Encoding")); encoding != "" && encoding != "identity" {
+		if decoder, ok := c.decoders[encoding]; ok {
+			resp.Body = &decodingReadCloser{body: resp.Body, decoder: decoder}
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			resp.Uncompressed = true
+		}
+	}
+
+	if limit := c.responseLimit(endpoint, class); limit > 0 {
+		resp.Body = &limitedReadCloser{ReadCloser: resp.Body, remaining: limit, limit: limit}
+	}
+}
+
+// decodingReadCloser decompresses a body, creating the decoder on first read
+// so that empty bodies (e.g. 304 Not Modified) don't fail.
+type decodingReadCloser struct {
+	body    io.ReadCloser
+	decoder ResponseDecoder
+	reader  io.ReadCloser
+	err     error
+}
+
+func (d *decodingReadCloser) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		d.reader, d.err = d.decoder(d.body)
+		if d.err != nil && d.err != io.EOF {
+			d.err = fmt.Errorf("failed to decompress response: %w", d.err)
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+func (d *decodingReadCloser) Close() error {
+	if d.reader != nil {
+		d.reader.Close()
+	}
+	return d.body.Close()
+}
+
+// limitedReadCloser fails with ErrResponseTooLarge once more than limit bytes are read.
+type limitedReadCloser struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Only fail if the body really has more data
+		var probe [1]byte
+		if n, err := l.ReadCloser.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, l.limit)
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// readLogsTail reads logs, keeping at most max bytes from their end.
+func readLogsTail(r io.Reader, max int64) (string, bool, error) {
+	if max <= 0 {
+		data, err := io.ReadAll(r)
+		return string(data), false, err
+	}
+
+	var (
+		buf       []byte
+		truncated bool
+		chunk     = make([]byte, 32<<10)
+	)
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if int64(len(buf)) > 2*max {
+			buf = append(buf[:0], buf[int64(len(buf))-max:]...)
+			truncated = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	if int64(len(buf)) > max {
+		buf = buf[int64(len(buf))-max:]
+		truncated = true
+	}
+	if truncated {
+		return TruncatedLogsMarker + string(buf), true, nil
+	}
+	return string(buf), false, nil
+}
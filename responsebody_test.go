@@ -0,0 +1,169 @@
+package ocfworker
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipResponses(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Accept-Encoding"), "gzip")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		json.NewEncoder(gz).Encode(NewJobResponse().WithID(jobID).Build())
+		gz.Close()
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	job, err := client.Jobs.Get(ctx, jobID.String())
+	require.NoError(t, err)
+	assert.Equal(t, jobID, job.ID)
+}
+
+func TestZstdResponses(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	jobID := uuid.New()
+	server.On("GET", "/api/v1/jobs/"+jobID.String(), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip, zstd", r.Header.Get("Accept-Encoding"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "zstd")
+		encoder, err := zstd.NewWriter(w)
+		require.NoError(t, err)
+		json.NewEncoder(encoder).Encode(NewJobResponse().WithID(jobID).Build())
+		encoder.Close()
+	})
+
+	client := server.TestClient()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	job, err := client.Jobs.Get(ctx, jobID.String())
+	require.NoError(t, err)
+	assert.Equal(t, jobID, job.ID)
+}
+
+func TestCustomResponseDecoder(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/storage/jobs/job-1/logs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "rot13, gzip, zstd", r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Encoding", "rot13")
+		w.Write([]byte("uryyb"))
+	})
+
+	client := server.TestClient(WithResponseDecoder("ROT13", func(r io.Reader) (io.ReadCloser, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		decoded := strings.Map(func(c rune) rune {
+			if c >= 'a' && c <= 'z' {
+				return 'a' + (c-'a'+13)%26
+			}
+			return c
+		}, string(data))
+		return io.NopCloser(strings.NewReader(decoded)), nil
+	}))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	logs, err := client.Storage.GetLogs(ctx, "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", logs)
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	files := make([]string, 100)
+	for i := range files {
+		files[i] = "slides.md"
+	}
+	server.On("GET", "/api/v1/storage/jobs/job-1/sources", func(w http.ResponseWriter, r *http.Request) {
+		RespondJSON(w, http.StatusOK, MockFileList(files...))
+	})
+
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	_, err := server.TestClient(WithMaxResponseSize(256)).Storage.ListSources(ctx, "job-1")
+	require.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.NotEmpty(t, RequestIDFromError(err))
+
+	// A heavy class limit doesn't apply to light endpoints
+	sources, err := server.TestClient(WithClassMaxResponseSize(EndpointClassHeavy, 256)).Storage.ListSources(ctx, "job-1")
+	require.NoError(t, err)
+	assert.Len(t, sources.Files, 100)
+}
+
+func TestGetLogsTruncation(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/storage/jobs/job-1/logs", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789ABCDEFGHIJ"))
+	})
+
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	logs, err := server.TestClient(WithMaxLogSize(10)).Storage.GetLogs(ctx, "job-1")
+	require.NoError(t, err)
+	assert.True(t, IsTruncatedLogs(logs))
+	assert.Equal(t, TruncatedLogsMarker+"ABCDEFGHIJ", logs)
+
+	logs, err = server.TestClient().Storage.GetLogs(ctx, "job-1")
+	require.NoError(t, err)
+	assert.False(t, IsTruncatedLogs(logs))
+	assert.Equal(t, "0123456789ABCDEFGHIJ", logs)
+}
+
+func TestReadLogsTail(t *testing.T) {
+	input := strings.Repeat("x", 200_000) + "the end"
+
+	logs, truncated, err := readLogsTail(strings.NewReader(input), 100)
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, logs, len(TruncatedLogsMarker)+100)
+	assert.True(t, strings.HasSuffix(logs, "xxthe end"))
+}
+
+func TestParseAPIError_LargeBody(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/jobs/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(strings.Repeat("<html>", 1<<20)))
+	})
+
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	_, err := server.TestClient().Jobs.Get(ctx, "huge")
+
+	AssertAPIError(t, err, http.StatusBadGateway, "")
+}
@@ -3,11 +3,12 @@ package ocfworker
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
 	"strings"
 
@@ -25,10 +26,16 @@ type FileUpload struct {
 	ContentType string
 }
 
-// StreamUpload représente un fichier à uploader en streaming
+// StreamUpload représente un fichier à uploader en streaming.
+//
+// Le contenu est lu depuis Reader ou, si Reader est nil, depuis Open : le
+// fichier n'est alors ouvert qu'au moment d'envoyer sa partie et fermé juste
+// après. Si Size est renseignée (> 0) pour tous les uploads, la requête est
+// envoyée avec un Content-Length au lieu d'être découpée en chunks.
 type StreamUpload struct {
 	Name        string
 	Reader      io.Reader
+	Open        func() (io.ReadCloser, error)
 	Size        int64
 	ContentType string
 }
@@ -39,7 +46,7 @@ func (s *StorageService) UploadSources(ctx context.Context, jobID string, files
 	writer := multipart.NewWriter(&buf)
 
 	for _, file := range files {
-		part, err := writer.CreateFormFile("files", file.Name)
+		part, err := writer.CreatePart(partHeader(file.Name, file.ContentType))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create form file: %w", err)
 		}
@@ -53,218 +60,280 @@ func (s *StorageService) UploadSources(ctx context.Context, jobID string, files
 		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		s.client.baseURL+fmt.Sprintf("/api/v1/storage/jobs/%s/sources", jobID), &buf)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return do[models.FileUploadResponse](ctx, s.client, &Request{
+		Method:         http.MethodPost,
+		Path:           fmt.Sprintf("/storage/jobs/%s/sources", jobID),
+		Body:           &buf,
+		ContentType:    writer.FormDataContentType(),
+		ExpectedStatus: []int{http.StatusCreated},
+	})
+}
 
-	resp, err := s.client.httpClient.Do(req)
+// UploadSourcesStream upload des fichiers sources en streaming
+func (s *StorageService) UploadSourcesStream(ctx context.Context, jobID string, uploads []StreamUpload) (*models.FileUploadResponse, error) {
+	// L'upload compte comme un appel en cours pour Close
+	ctx, end, err := s.client.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		return nil, parseAPIError(resp)
-	}
+	defer end()
 
-	var uploadResp models.FileUploadResponse
-	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &uploadResp, nil
-}
-
-// UploadSourcesStream upload des fichiers sources en streaming
-func (s *StorageService) UploadSourcesStream(ctx context.Context, jobID string, uploads []StreamUpload) (*models.FileUploadResponse, error) {
 	pr, pw := io.Pipe()
 	writer := multipart.NewWriter(pw)
 
-	go func() {
-		defer pw.Close()
-		defer writer.Close()
-
-		for _, upload := range uploads {
-			part, err := writer.CreateFormFile("files", upload.Name)
-			if err != nil {
-				pw.CloseWithError(err)
-				return
-			}
-
-			if _, err := io.Copy(part, upload.Reader); err != nil {
-				pw.CloseWithError(err)
-				return
-			}
-		}
-	}()
-
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		s.client.baseURL+fmt.Sprintf("/api/v1/storage/jobs/%s/sources", jobID), pr)
+	contentLength, err := multipartLength(writer.Boundary(), uploads)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to compute upload size: %w", err)
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	// Fermer le pipe quand l'appel se termine débloque l'écriture en
+	// arrière-plan, même si la requête a échoué avant de lire le corps.
+	// En cas d'annulation (ex: Close), il est fermé immédiatement : le
+	// transport attend la fin de la lecture du corps avant de rendre la main.
+	defer pr.Close()
+	stop := context.AfterFunc(ctx, func() {
+		pr.CloseWithError(ctx.Err())
+	})
+	defer stop()
+
+	writeErr := make(chan error, 1)
+	go func() {
+		err := writeParts(writer, uploads)
+		// L'erreur est publiée avant de fermer le pipe : elle est disponible
+		// dès que la requête échoue à cause d'elle
+		writeErr <- err
+		pw.CloseWithError(err)
+	}()
 
-	resp, err := s.client.httpClient.Do(req)
+	result, err := do[models.FileUploadResponse](ctx, s.client, &Request{
+		Method:         http.MethodPost,
+		Path:           fmt.Sprintf("/storage/jobs/%s/sources", jobID),
+		Body:           pr,
+		ContentType:    writer.FormDataContentType(),
+		ContentLength:  contentLength,
+		ExpectedStatus: []int{http.StatusCreated},
+	})
 	if err != nil {
+		// Remonter la cause réelle si l'échec vient de la lecture des
+		// fichiers. io.ErrClosedPipe signifie seulement que la requête s'est
+		// terminée sans lire tout le corps (ex: 413) : son erreur prime.
+		select {
+		case werr := <-writeErr:
+			if werr != nil && !errors.Is(werr, io.ErrClosedPipe) {
+				return nil, fmt.Errorf("failed to write upload body: %w", werr)
+			}
+		default:
+		}
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return nil, parseAPIError(resp)
-	}
-
-	var uploadResp models.FileUploadResponse
-	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &uploadResp, nil
+	return result, nil
 }
 
-// UploadSourceFiles helper pour uploader des fichiers depuis le système de fichiers
+// UploadSourceFiles helper pour uploader des fichiers depuis le système de fichiers.
+// Chaque fichier n'est ouvert que pendant l'envoi de sa partie.
 func (s *StorageService) UploadSourceFiles(ctx context.Context, jobID string, filePaths []string) (*models.FileUploadResponse, error) {
-	var uploads []StreamUpload
+	uploads := make([]StreamUpload, 0, len(filePaths))
 
 	for _, path := range filePaths {
-		file, err := os.Open(path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open file %s: %w", path, err)
-		}
-		defer file.Close()
-
-		stat, err := file.Stat()
+		stat, err := os.Stat(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to stat file %s: %w", path, err)
 		}
 
 		uploads = append(uploads, StreamUpload{
 			Name:        stat.Name(),
-			Reader:      file,
 			Size:        stat.Size(),
 			ContentType: detectContentType(path),
+			Open: func() (io.ReadCloser, error) {
+				file, err := os.Open(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+				}
+				return file, nil
+			},
 		})
 	}
 
 	return s.UploadSourcesStream(ctx, jobID, uploads)
 }
 
-// ListSources liste les fichiers sources d'un job
-func (s *StorageService) ListSources(ctx context.Context, jobID string) (*models.FileListResponse, error) {
-	resp, err := s.client.get(ctx, fmt.Sprintf("/storage/jobs/%s/sources", jobID))
+// quoteEscaper échappe les noms de fichiers comme mime/multipart
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// partHeader construit les en-têtes d'une partie du formulaire d'upload
+func partHeader(name, contentType string) textproto.MIMEHeader {
+	if contentType == "" {
+		contentType = detectContentType(name)
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files"; filename="%s"`, quoteEscaper.Replace(name)))
+	header.Set("Content-Type", contentType)
+	return header
+}
+
+// writeParts écrit les uploads dans le formulaire multipart, puis le termine
+func writeParts(writer *multipart.Writer, uploads []StreamUpload) error {
+	for _, upload := range uploads {
+		if err := writePart(writer, upload); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	return nil
+}
+
+// writePart écrit un upload, en ouvrant et fermant son contenu si besoin
+func writePart(writer *multipart.Writer, upload StreamUpload) error {
+	reader := upload.Reader
+	if reader == nil {
+		if upload.Open == nil {
+			return fmt.Errorf("no content for %s: Reader or Open is required", upload.Name)
+		}
+		rc, err := upload.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		reader = rc
+	}
+
+	part, err := writer.CreatePart(partHeader(upload.Name, upload.ContentType))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to create form file: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
+	if upload.Size <= 0 {
+		if _, err := io.Copy(part, reader); err != nil {
+			return fmt.Errorf("failed to read %s: %w", upload.Name, err)
+		}
+		return nil
 	}
 
-	var sources models.FileListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&sources); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	// Taille annoncée : le contenu doit la respecter, le Content-Length en dépend
+	n, err := io.CopyN(part, reader, upload.Size)
+	if err == io.EOF {
+		return fmt.Errorf("size mismatch for %s: expected %d bytes, got %d", upload.Name, upload.Size, n)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", upload.Name, err)
 	}
+	if extra, _ := reader.Read(make([]byte, 1)); extra > 0 {
+		return fmt.Errorf("size mismatch for %s: more than %d bytes", upload.Name, upload.Size)
+	}
+	return nil
+}
 
-	return &sources, nil
+// multipartLength calcule la taille du formulaire multipart quand la taille
+// de chaque upload est connue, 0 sinon
+func multipartLength(boundary string, uploads []StreamUpload) (int64, error) {
+	var framing byteCounter
+	writer := multipart.NewWriter(&framing)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, upload := range uploads {
+		if upload.Size <= 0 {
+			return 0, nil
+		}
+		if _, err := writer.CreatePart(partHeader(upload.Name, upload.ContentType)); err != nil {
+			return 0, err
+		}
+		total += upload.Size
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	return total + int64(framing), nil
+}
+
+// byteCounter compte les octets écrits
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// ListSources liste les fichiers sources d'un job
+func (s *StorageService) ListSources(ctx context.Context, jobID string) (*models.FileListResponse, error) {
+	return do[models.FileListResponse](ctx, s.client, &Request{
+		Path: fmt.Sprintf("/storage/jobs/%s/sources", jobID),
+	})
 }
 
 // DownloadSource télécharge un fichier source spécifique
 func (s *StorageService) DownloadSource(ctx context.Context, jobID, filename string) (io.ReadCloser, error) {
-	resp, err := s.client.get(ctx, fmt.Sprintf("/storage/jobs/%s/sources/%s", jobID, filename))
+	resp, err := s.client.doChecked(ctx, &Request{
+		Path: fmt.Sprintf("/storage/jobs/%s/sources/%s", jobID, filename),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, parseAPIError(resp)
-	}
-
 	return resp.Body, nil
 }
 
 // ListResults liste les fichiers de résultats d'un cours
 func (s *StorageService) ListResults(ctx context.Context, courseID string) (*models.FileListResponse, error) {
-	resp, err := s.client.get(ctx, fmt.Sprintf("/storage/courses/%s/results", courseID))
+	results, err := do[models.FileListResponse](ctx, s.client, &Request{
+		Path: fmt.Sprintf("/storage/courses/%s/results", courseID),
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var results models.FileListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
 
 	results.Count = len(results.Files)
 
-	return &results, nil
+	return results, nil
 }
 
 // DownloadResult télécharge un fichier de résultat spécifique
 func (s *StorageService) DownloadResult(ctx context.Context, courseID, filename string) (io.ReadCloser, error) {
-	resp, err := s.client.get(ctx, fmt.Sprintf("/storage/courses/%s/results/%s", courseID, filename))
+	resp, err := s.client.doChecked(ctx, &Request{
+		Path: fmt.Sprintf("/storage/courses/%s/results/%s", courseID, filename),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, parseAPIError(resp)
-	}
-
 	return resp.Body, nil
 }
 
 // GetLogs récupère les logs d'un job
 func (s *StorageService) GetLogs(ctx context.Context, jobID string) (string, error) {
-	resp, err := s.client.get(ctx, fmt.Sprintf("/storage/jobs/%s/logs", jobID))
+	ctx = withRequiredFeature(ctx, FeatureLogs)
+
+	resp, err := s.client.doChecked(ctx, &Request{
+		Path: fmt.Sprintf("/storage/jobs/%s/logs", jobID),
+	})
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", parseAPIError(resp)
-	}
-
-	logs, err := io.ReadAll(resp.Body)
+	// Les logs trop volumineux sont tronqués par le début plutôt que chargés en entier
+	logs, truncated, err := readLogsTail(resp.Body, s.client.maxLogSize)
 	if err != nil {
-		return "", fmt.Errorf("failed to read logs: %w", err)
+		return "", newResponseError(resp, fmt.Errorf("failed to read logs: %w", err))
+	}
+	if truncated {
+		s.client.logger.Warn("Job logs truncated", LogKeyJobID, jobID, "max_size", s.client.maxLogSize)
 	}
 
-	return string(logs), nil
+	return logs, nil
 }
 
 // GetStorageInfo récupère les informations sur le stockage
 func (s *StorageService) GetStorageInfo(ctx context.Context) (*models.StorageInfo, error) {
-	resp, err := s.client.get(ctx, "/storage/info")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var info models.StorageInfo
-	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &info, nil
+	return do[models.StorageInfo](ctx, s.client, &Request{Path: "/storage/info"})
 }
 
 // detectContentType détecte le type MIME basé sur l'extension
@@ -2,6 +2,7 @@ package ocfworker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Open-Course-Factory/ocf-worker/pkg/models"
 	"github.com/google/uuid"
@@ -225,6 +227,153 @@ func TestStorageService_UploadSourcesStream(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "simulated read error")
 	})
+
+	t.Run("content length and part headers", func(t *testing.T) {
+		jobID := uuid.New().String()
+		uploads := []StreamUpload{
+			{Name: "slides.md", Reader: strings.NewReader("# Slides"), Size: 8, ContentType: "text/markdown"},
+			{Name: `logo "v2".png`, Reader: strings.NewReader("PNG"), Size: 3},
+		}
+
+		server.On("POST", "/api/v1/storage/jobs/"+jobID+"/sources", func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(body)), r.ContentLength)
+			assert.Empty(t, r.TransferEncoding)
+
+			r.Body = io.NopCloser(strings.NewReader(string(body)))
+			require.NoError(t, r.ParseMultipartForm(32<<20))
+			files := r.MultipartForm.File["files"]
+			require.Len(t, files, 2)
+			assert.Equal(t, "text/markdown", files[0].Header.Get("Content-Type"))
+			assert.Equal(t, `logo "v2".png`, files[1].Filename)
+			assert.Equal(t, "image/png", files[1].Header.Get("Content-Type"))
+
+			RespondJSON(w, http.StatusCreated, &models.FileUploadResponse{Count: 2})
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient().Storage.UploadSourcesStream(ctx, jobID, uploads)
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown size is sent chunked", func(t *testing.T) {
+		jobID := uuid.New().String()
+		server.On("POST", "/api/v1/storage/jobs/"+jobID+"/sources", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, int64(-1), r.ContentLength)
+			assert.Equal(t, []string{"chunked"}, r.TransferEncoding)
+			io.Copy(io.Discard, r.Body)
+			RespondJSON(w, http.StatusCreated, &models.FileUploadResponse{Count: 2})
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient().Storage.UploadSourcesStream(ctx, jobID, []StreamUpload{
+			{Name: "known.md", Reader: strings.NewReader("known"), Size: 5},
+			{Name: "unknown.md", Reader: strings.NewReader("unknown")},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		jobID := uuid.New().String()
+		server.On("POST", "/api/v1/storage/jobs/"+jobID+"/sources", func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			RespondJSON(w, http.StatusCreated, &models.FileUploadResponse{Count: 1})
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient().Storage.UploadSourcesStream(ctx, jobID, []StreamUpload{
+			{Name: "slides.md", Reader: strings.NewReader("short"), Size: 100},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "size mismatch for slides.md")
+	})
+
+	t.Run("request failure stops the writer", func(t *testing.T) {
+		closed := make(chan struct{})
+		uploads := []StreamUpload{{
+			Name: "big.md",
+			Open: func() (io.ReadCloser, error) {
+				return &closeNotifier{Reader: strings.NewReader(strings.Repeat("x", 1<<20)), onClose: func() { close(closed) }}, nil
+			},
+		}}
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		// The request fails before the body is read
+		client := server.TestClient(WithProxy("ftp://invalid"))
+		_, err := client.Storage.UploadSourcesStream(ctx, uuid.New().String(), uploads)
+		require.Error(t, err)
+
+		select {
+		case <-closed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("the writer goroutine is still blocked")
+		}
+	})
+
+	t.Run("server rejects the body without reading it", func(t *testing.T) {
+		jobID := uuid.New().String()
+		server.On("POST", "/api/v1/storage/jobs/"+jobID+"/sources", func(w http.ResponseWriter, r *http.Request) {
+			RespondError(w, http.StatusRequestEntityTooLarge, "upload too large")
+		})
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient().Storage.UploadSourcesStream(ctx, jobID, []StreamUpload{
+			{Name: "big.md", Reader: strings.NewReader(strings.Repeat("x", 8<<20)), Size: 8 << 20},
+		})
+		require.Error(t, err)
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr), "got %v", err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, apiErr.StatusCode)
+	})
+
+	t.Run("files are opened one at a time", func(t *testing.T) {
+		jobID := uuid.New().String()
+		server.On("POST", "/api/v1/storage/jobs/"+jobID+"/sources", func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			RespondJSON(w, http.StatusCreated, &models.FileUploadResponse{Count: 3})
+		})
+
+		var open int
+		uploads := make([]StreamUpload, 3)
+		for i := range uploads {
+			uploads[i] = StreamUpload{
+				Name: fmt.Sprintf("file%d.md", i),
+				Open: func() (io.ReadCloser, error) {
+					open++
+					assert.Equal(t, 1, open, "previous file not closed")
+					return &closeNotifier{Reader: strings.NewReader("content"), onClose: func() { open-- }}, nil
+				},
+			}
+		}
+
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := server.TestClient().Storage.UploadSourcesStream(ctx, jobID, uploads)
+		require.NoError(t, err)
+	})
+}
+
+// closeNotifier calls onClose when it is closed
+type closeNotifier struct {
+	io.Reader
+	onClose func()
+}
+
+func (c *closeNotifier) Close() error {
+	c.onClose()
+	return nil
 }
 
 func TestStorageService_UploadSourceFiles(t *testing.T) {
@@ -253,6 +402,9 @@ func TestStorageService_UploadSourceFiles(t *testing.T) {
 		}
 
 		server.On("POST", "/api/v1/storage/jobs/"+jobID+"/sources", func(w http.ResponseWriter, r *http.Request) {
+			// File sizes are known: no chunked encoding
+			assert.Greater(t, r.ContentLength, int64(0))
+
 			err := r.ParseMultipartForm(32 << 20)
 			require.NoError(t, err)
 
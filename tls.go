@@ -0,0 +1,224 @@
+package ocfworker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithTLSConfig sets the TLS configuration used to connect to the worker.
+// The configuration is cloned; WithClientCertificate and WithRootCAs are
+// applied on top of it.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL, ocfworker.WithTLSConfig(&tls.Config{
+//		MinVersion: tls.VersionTLS13,
+//		ServerName: "worker.internal",
+//	}))
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config.Clone()
+	}
+}
+
+// WithClientCertificate authenticates the client with a certificate (mTLS),
+// loaded from PEM-encoded certificate and key files. Both files are required:
+// calls fail with a configuration error when only one is given.
+//
+// The files are watched: when they change (e.g. after a certificate
+// rotation), the new certificate is used for the next connections, without
+// recreating the client.
+//
+// Example:
+//
+//	client := ocfworker.NewClient(baseURL,
+//		ocfworker.WithClientCertificate("/etc/ocf/client.crt", "/etc/ocf/client.key"),
+//		ocfworker.WithRootCAs("/etc/ocf/ca.pem"),
+//	)
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *Client) {
+		c.certFile = certFile
+		c.keyFile = keyFile
+	}
+}
+
+// WithRootCAs trusts the certificate authorities of a PEM file, instead of
+// the system pool, to verify the worker's certificate.
+//
+// The file is watched like the client certificate: when it changes (e.g.
+// after a CA rotation), new connections trust the new bundle, without
+// recreating the client; established connections are kept. During a CA
+// rotation, include both the old and the new CA in the file until every
+// worker presents a certificate from the new CA.
+func WithRootCAs(pemFile string) Option {
+	return func(c *Client) {
+		c.rootCAFile = pemFile
+	}
+}
+
+// hasTLSOptions tells whether any TLS option was set.
+func (c *Client) hasTLSOptions() bool {
+	return c.tlsConfig != nil || c.certFile != "" || c.keyFile != "" || c.rootCAFile != ""
+}
+
+// buildTLSConfig merges WithTLSConfig and WithClientCertificate. The root
+// CAs of WithRootCAs are set by a rootCAsTransport.
+func (c *Client) buildTLSConfig() (*tls.Config, error) {
+	config := c.tlsConfig
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if c.certFile != "" || c.keyFile != "" {
+		if c.certFile == "" || c.keyFile == "" {
+			return nil, errors.New("client certificate requires both a certificate and a key file")
+		}
+		reloader, err := newCertReloader(c.certFile, c.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = reloader.clientCertificate
+	}
+
+	return config, nil
+}
+
+// certReloader serves a client certificate and reloads it when its files change.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certTime time.Time
+	keyTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads the certificate if its files changed since the last load.
+// Must be called with mu held, or before the reloader is shared.
+func (r *certReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	if r.cert != nil && certInfo.ModTime().Equal(r.certTime) && keyInfo.ModTime().Equal(r.keyTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.certTime = certInfo.ModTime()
+	r.keyTime = keyInfo.ModTime()
+	return nil
+}
+
+// clientCertificate implements tls.Config.GetClientCertificate. When the
+// files were changed but can't be loaded (e.g. during a non-atomic rotation),
+// the previous certificate is kept.
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.reload(); err != nil && r.cert == nil {
+		return nil, err
+	}
+	return r.cert, nil
+}
+
+// rootCAsTransport sends requests through a transport trusting the
+// certificate authorities of a PEM file, and switches to a new transport when
+// the file changes. Requests in progress keep their connection; the idle
+// connections of the previous transport are closed.
+type rootCAsTransport struct {
+	// base is the transport cloned for each version of the file
+	base   *http.Transport
+	caFile string
+
+	mu      sync.Mutex
+	current *http.Transport
+	modTime time.Time
+}
+
+func newRootCAsTransport(base *http.Transport, caFile string) (*rootCAsTransport, error) {
+	t := &rootCAsTransport{base: base, caFile: caFile}
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// reload builds a new transport if the file changed since the last load.
+// Must be called with mu held, or before the transport is shared.
+func (t *rootCAsTransport) reload() error {
+	info, err := os.Stat(t.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read root CAs: %w", err)
+	}
+	if t.current != nil && info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+
+	pem, err := os.ReadFile(t.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read root CAs: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificate found in %s", t.caFile)
+	}
+
+	transport := t.base.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	transport.TLSClientConfig.RootCAs = pool
+
+	if t.current != nil {
+		t.current.CloseIdleConnections()
+	}
+	t.current = transport
+	t.modTime = info.ModTime()
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper. When the file was changed but
+// can't be loaded (e.g. during a non-atomic rotation), the previous CAs are
+// kept.
+func (t *rootCAsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.reload()
+	transport := t.current
+	t.mu.Unlock()
+
+	return transport.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *rootCAsTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current.CloseIdleConnections()
+}
@@ -0,0 +1,271 @@
+package ocfworker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for the TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key signed by the CA
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// newMTLSServer starts a server requiring a client certificate signed by the CA.
+// It answers health checks with the common name of the client certificate.
+func newMTLSServer(t *testing.T, ca *testCA) *httptest.Server {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, "worker", x509.ExtKeyUsageServerAuth)
+	serverCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// New connection for each request, so that each one performs a handshake
+		w.Header().Set("Connection", "close")
+		w.Header().Set("X-Client-CN", r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		RespondJSON(w, http.StatusOK, MockHealthResponse("healthy"))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	server := newMTLSServer(t, ca)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	writeFile(t, caFile, ca.pem)
+	certPEM, keyPEM := ca.issue(t, "client-1", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	client := NewClient(server.URL,
+		WithAuth("secret-token"),
+		WithHTTPClient(&http.Client{Timeout: 5 * time.Second}),
+		WithRootCAs(caFile),
+		WithClientCertificate(certFile, keyFile),
+	)
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	resp, err := client.get(ctx, "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "client-1", resp.Header.Get("X-Client-CN"))
+	assert.Equal(t, "Bearer secret-token", resp.Header.Get("X-Authorization"))
+
+	t.Run("certificate hot reload", func(t *testing.T) {
+		certPEM, keyPEM := ca.issue(t, "client-2", x509.ExtKeyUsageClientAuth)
+		writeFile(t, certFile, certPEM)
+		writeFile(t, keyFile, keyPEM)
+		// Make sure the modification time changes on coarse-grained file systems
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, later, later))
+		require.NoError(t, os.Chtimes(keyFile, later, later))
+
+		resp, err := client.get(ctx, "/health")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "client-2", resp.Header.Get("X-Client-CN"))
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		_, err := NewClient(server.URL, WithRootCAs(caFile)).get(ctx, "/health")
+		require.Error(t, err)
+	})
+
+	t.Run("unknown CA", func(t *testing.T) {
+		_, err := NewClient(server.URL, WithClientCertificate(certFile, keyFile)).get(ctx, "/health")
+		require.Error(t, err)
+	})
+}
+
+func TestRootCAsReload(t *testing.T) {
+	ca := newTestCA(t)
+	otherCA := newTestCA(t)
+
+	certPEM, keyPEM := ca.issue(t, "worker", x509.ExtKeyUsageServerAuth)
+	serverCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		RespondJSON(w, http.StatusOK, MockHealthResponse("healthy"))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, ca.pem)
+	client := NewClient(server.URL, WithRootCAs(caFile))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	rotate := func(t *testing.T, pem []byte, at time.Time) {
+		t.Helper()
+		writeFile(t, caFile, pem)
+		// Make sure the modification time changes on coarse-grained file systems
+		require.NoError(t, os.Chtimes(caFile, at, at))
+	}
+
+	_, err = client.Health.Check(ctx)
+	require.NoError(t, err)
+
+	// The bundle no longer trusts the worker's CA
+	rotate(t, otherCA.pem, time.Now().Add(time.Minute))
+	_, err = client.Health.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "certificate signed by unknown authority")
+
+	// A bundle being rewritten is ignored until it is valid again
+	rotate(t, []byte("partial"), time.Now().Add(2*time.Minute))
+	_, err = client.Health.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "certificate signed by unknown authority")
+
+	rotate(t, append(otherCA.pem, ca.pem...), time.Now().Add(3*time.Minute))
+	_, err = client.Health.Check(ctx)
+	require.NoError(t, err)
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	client := NewClient("https://localhost", WithClientCertificate(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key")))
+	_, err := client.Health.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load client certificate")
+	assert.NotEmpty(t, RequestIDFromError(err))
+
+	for _, files := range [][2]string{{"", filepath.Join(dir, "client.key")}, {filepath.Join(dir, "client.crt"), ""}} {
+		_, err = NewClient("https://localhost", WithClientCertificate(files[0], files[1])).Health.Check(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires both a certificate and a key file")
+	}
+
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, []byte("not a certificate"))
+	_, err = NewClient("https://localhost", WithRootCAs(caFile)).Health.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no certificate found")
+
+	custom := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	_, err = NewClient("https://localhost", WithHTTPClient(custom), WithRootCAs(caFile)).Health.Check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "require the HTTP client to use an *http.Transport")
+}
+
+func TestWithAuthAndHTTPClient(t *testing.T) {
+	server := NewTestServer()
+	defer server.Close()
+
+	server.On("GET", "/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-123", r.Header.Get("Authorization"))
+		RespondJSON(w, http.StatusOK, MockHealthResponse("healthy"))
+	})
+
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	// The order of the options doesn't matter
+	client := NewClient(server.URL, WithAuth("token-123"), WithHTTPClient(httpClient), WithTimeout(time.Second))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	_, err := client.Health.Check(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, httpClient.Transport, "the provided HTTP client is not modified")
+	assert.Equal(t, 5*time.Second, httpClient.Timeout, "the provided HTTP client is not modified")
+	assert.Equal(t, time.Second, client.httpClient.Timeout)
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,187 @@
+package ocfworker
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthHandler answers health checks and checks the Authorization header
+func healthHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/health", r.URL.Path)
+		assert.Equal(t, "Bearer token-123", r.Header.Get("Authorization"))
+		RespondJSON(w, http.StatusOK, MockHealthResponse("healthy"))
+	}
+}
+
+func TestUnixSocketBaseURL(t *testing.T) {
+	// Socket paths are limited to ~100 bytes: t.TempDir() may be too long
+	dir, err := os.MkdirTemp("", "ocf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "worker.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(healthHandler(t))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client := NewClient("unix://"+socketPath, WithAuth("token-123"))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	health, err := client.Health.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+}
+
+func TestWithProxy(t *testing.T) {
+	t.Run("http proxy", func(t *testing.T) {
+		var proxied atomic.Value
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A proxy receives the absolute URL of the target
+			proxied.Store(r.URL.String())
+			healthHandler(t)(w, r)
+		}))
+		defer proxy.Close()
+
+		client := NewClient("http://worker.invalid", WithProxy(proxy.URL), WithAuth("token-123"))
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := client.Health.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "http://worker.invalid/api/v1/health", proxied.Load())
+	})
+
+	t.Run("socks5 proxy", func(t *testing.T) {
+		server := httptest.NewServer(healthHandler(t))
+		defer server.Close()
+
+		proxyAddr, requested := startSOCKS5Proxy(t, server.Listener.Addr().String())
+		// Host names are resolved by the proxy with socks5h
+		client := NewClient("http://worker.invalid:8081", WithProxy("socks5h://"+proxyAddr), WithAuth("token-123"))
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := client.Health.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "worker.invalid:8081", <-requested)
+	})
+
+	t.Run("invalid proxy", func(t *testing.T) {
+		ctx, cancel := TestContext()
+		defer cancel()
+
+		_, err := NewClient("http://worker.invalid", WithProxy("ftp://proxy.corp")).Health.Check(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported proxy scheme "ftp"`)
+	})
+}
+
+func TestWithDialContext(t *testing.T) {
+	server := httptest.NewServer(healthHandler(t))
+	defer server.Close()
+
+	var dialed atomic.Int32
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed.Add(1)
+		assert.Equal(t, "worker.invalid:80", address)
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, server.Listener.Addr().String())
+	}
+
+	client := NewClient("http://worker.invalid", WithDialContext(dial), WithAuth("token-123"))
+	ctx, cancel := TestContext()
+	defer cancel()
+
+	_, err := client.Health.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), dialed.Load())
+}
+
+// startSOCKS5Proxy starts a minimal SOCKS5 proxy (no authentication, CONNECT
+// only) forwarding every connection to target. It reports the requested
+// destinations on the returned channel.
+func startSOCKS5Proxy(t *testing.T, target string) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	requested := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+
+				// Greeting: version, methods -> no authentication
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+					return
+				}
+				conn.Write([]byte{5, 0})
+
+				// Request: version, CONNECT, reserved, address type
+				request := make([]byte, 4)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				var host string
+				switch request[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					length := make([]byte, 1)
+					io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				port := make([]byte, 2)
+				if _, err := io.ReadFull(conn, port); err != nil {
+					return
+				}
+				requested <- net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), requested
+}
@@ -1,11 +1,8 @@
 package ocfworker
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -19,28 +16,21 @@ type WorkerService struct {
 
 // Health vérifie la santé du système de workers
 func (s *WorkerService) Health(ctx context.Context) (*models.WorkerHealthResponse, error) {
-	resp, err := s.client.get(ctx, "/worker/health")
+	resp, err := s.client.doRaw(ctx, &Request{Path: "/worker/health"})
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Lire le body une seule fois
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	// Le code de retour peut être 200 ou 503 selon l'état ; seul le corps de ces
+	// réponses est un WorkerHealthResponse
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, parseAPIError(resp)
 	}
 
 	var health models.WorkerHealthResponse
-	if err := json.Unmarshal(body, &health); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Le code de retour peut être 200 ou 503 selon l'état
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
-		// Créer un nouveau reader pour parseAPIError
-		resp.Body = io.NopCloser(bytes.NewReader(body))
-		return &health, parseAPIError(resp)
+	if err := s.client.decodeResponse(resp, &health); err != nil {
+		return nil, err
 	}
 
 	return &health, nil
@@ -48,26 +38,15 @@ func (s *WorkerService) Health(ctx context.Context) (*models.WorkerHealthRespons
 
 // Stats retourne les statistiques du pool de workers
 func (s *WorkerService) Stats(ctx context.Context) (*models.WorkerStatsResponse, error) {
-	resp, err := s.client.get(ctx, "/worker/stats")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var stats models.WorkerStatsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+	ctx = withRequiredFeature(ctx, FeatureWorkerStats)
 
-	return &stats, nil
+	return do[models.WorkerStatsResponse](ctx, s.client, &Request{Path: "/worker/stats"})
 }
 
 // ListWorkspaces liste les workspaces actifs
 func (s *WorkerService) ListWorkspaces(ctx context.Context, opts *ListWorkspacesOptions) (*models.WorkspaceListResponse, error) {
+	ctx = withRequiredFeature(ctx, FeatureWorkspaces)
+
 	params := url.Values{}
 
 	if opts != nil {
@@ -82,115 +61,55 @@ func (s *WorkerService) ListWorkspaces(ctx context.Context, opts *ListWorkspaces
 		}
 	}
 
-	path := "/worker/workspaces"
-	if len(params) > 0 {
-		path += "?" + params.Encode()
-	}
-
-	resp, err := s.client.get(ctx, path)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var workspaces models.WorkspaceListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&workspaces); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &workspaces, nil
+	return do[models.WorkspaceListResponse](ctx, s.client, &Request{Path: "/worker/workspaces", Query: params})
 }
 
 // GetWorkspace retourne les informations d'un workspace spécifique
 func (s *WorkerService) GetWorkspace(ctx context.Context, jobID string) (*models.WorkspaceInfoResponse, error) {
-	resp, err := s.client.get(ctx, fmt.Sprintf("/worker/workspaces/%s", jobID))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	ctx = withRequiredFeature(ctx, FeatureWorkspaces)
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("workspace not found for job %s", jobID)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var workspace models.WorkspaceInfoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&workspace); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &workspace, nil
+	return do[models.WorkspaceInfoResponse](ctx, s.client, &Request{
+		Path:     fmt.Sprintf("/worker/workspaces/%s", jobID),
+		notFound: workspaceNotFound(jobID),
+	})
 }
 
 // DeleteWorkspace supprime un workspace
 func (s *WorkerService) DeleteWorkspace(ctx context.Context, jobID string) (*models.WorkspaceCleanupResponse, error) {
-	s.client.logger.Warn("Deleting workspace", "job_id", jobID)
+	ctx = withRequiredFeature(ctx, FeatureWorkspaces)
 
-	req, err := http.NewRequestWithContext(ctx, "DELETE",
-		s.client.baseURL+fmt.Sprintf("/api/v1/worker/workspaces/%s", jobID), nil)
-	if err != nil {
-		return nil, err
-	}
+	s.client.logger.Warn("Deleting workspace", LogKeyJobID, jobID)
 
-	resp, err := s.client.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("workspace not found for job %s", jobID)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
-
-	var cleanup models.WorkspaceCleanupResponse
-	if err := json.NewDecoder(resp.Body).Decode(&cleanup); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return &cleanup, nil
+	return do[models.WorkspaceCleanupResponse](ctx, s.client, &Request{
+		Method:   http.MethodDelete,
+		Path:     fmt.Sprintf("/worker/workspaces/%s", jobID),
+		notFound: workspaceNotFound(jobID),
+	})
 }
 
 // CleanupOldWorkspaces nettoie les anciens workspaces
 func (s *WorkerService) CleanupOldWorkspaces(ctx context.Context, maxAgeHours int) (*models.WorkspaceCleanupBatchResponse, error) {
+	ctx = withRequiredFeature(ctx, FeatureWorkspaces)
+
 	params := url.Values{}
 	if maxAgeHours > 0 {
 		params.Set("max_age_hours", strconv.Itoa(maxAgeHours))
 	}
 
-	path := "/worker/workspaces/cleanup"
-	if len(params) > 0 {
-		path += "?" + params.Encode()
-	}
-
 	s.client.logger.Info("Cleaning up old workspaces", "max_age_hours", maxAgeHours)
 
-	resp, err := s.client.post(ctx, path, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, parseAPIError(resp)
-	}
+	return do[models.WorkspaceCleanupBatchResponse](ctx, s.client, &Request{
+		Method: http.MethodPost,
+		Path:   "/worker/workspaces/cleanup",
+		Query:  params,
+	})
+}
 
-	var cleanup models.WorkspaceCleanupBatchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&cleanup); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// workspaceNotFound construit l'erreur retournée quand le workspace d'un job n'existe pas
+func workspaceNotFound(jobID string) func(resp *http.Response) error {
+	return func(resp *http.Response) error {
+		return newResponseError(resp, fmt.Errorf("workspace not found for job %s", jobID))
 	}
-
-	return &cleanup, nil
 }
 
 type ListWorkspacesOptions struct {